@@ -0,0 +1,260 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//
+// buildCache contains the manifest of the last build, stored as JSON in
+// file ".ciigo-cache" inside the root directory.
+//
+// The manifest record the ciigo version, the hash of HTML template, the
+// hash of navigation tree, the URL of search page, the hash of options that
+// affect the generated HTML files, and the hash and page metadata of each
+// markup file.
+// A generated HTML file is considered stale if its markup file has
+// different hash than the one recorded in the manifest, or if the ciigo
+// version, the HTML template, the navigation tree, the search page, or the
+// options has changed since the last build.
+//
+type buildCache struct {
+	Version  string                      `json:"version"`
+	Template string                      `json:"template"`
+	Nav      string                      `json:"nav"`
+	Search   string                      `json:"search,omitempty"`
+	Options  string                      `json:"options,omitempty"`
+	Files    map[string]*buildCacheEntry `json:"files"`
+
+	// Generated contains the list of generated files, other than the
+	// HTML files from markup files, relative to the output directory.
+	Generated []string `json:"generated,omitempty"`
+
+	dir  string
+	root string
+	mu   sync.Mutex
+}
+
+//
+// buildCacheEntry contains the cache of single markup file.
+//
+type buildCacheEntry struct {
+	Hash string `json:"hash"`
+	pageInfo
+}
+
+//
+// newBuildCache load the build manifest from directory "dir".
+// Each markup file is recorded using its path relative to the "root"
+// directory.
+// If the manifest does not exist, is invalid, or created by different ciigo
+// version or HTML template, it will return an empty cache.
+//
+func newBuildCache(dir, root, tmplContent string) (bc *buildCache) {
+	bc = &buildCache{
+		dir:  dir,
+		root: root,
+	}
+
+	b, err := ioutil.ReadFile(bc.path())
+	if err == nil {
+		err = json.Unmarshal(b, bc)
+		if err != nil {
+			log.Printf("ciigo: invalid build cache %s: %s", bc.path(), err)
+		}
+	} else if !os.IsNotExist(err) {
+		log.Printf("ciigo: newBuildCache: %s", err)
+	}
+
+	bc.setTemplate(tmplContent)
+
+	return bc
+}
+
+//
+// contentHash return the hex string of SHA-256 of content.
+//
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+//
+// isLatest will return true if the markup file in "path" has the same hash
+// as recorded in the cache and its HTML file is exist.
+//
+func (bc *buildCache) isLatest(path, hash, htmlPath string) bool {
+	bc.mu.Lock()
+	entry := bc.Files[bc.key(path)]
+	bc.mu.Unlock()
+
+	if entry == nil || entry.Hash != hash {
+		return false
+	}
+
+	_, err := os.Stat(htmlPath)
+
+	return err == nil
+}
+
+//
+// getPage return the page metadata of markup file in "path" from the last
+// build.
+//
+func (bc *buildCache) getPage(path string) (page pageInfo) {
+	bc.mu.Lock()
+	entry := bc.Files[bc.key(path)]
+	if entry != nil {
+		page = entry.pageInfo
+	}
+	bc.mu.Unlock()
+	return page
+}
+
+func (bc *buildCache) key(path string) string {
+	rel, err := filepath.Rel(bc.root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (bc *buildCache) path() string {
+	return filepath.Join(bc.dir, fileBuildCache)
+}
+
+//
+// remove the markup file in "path" from cache.
+//
+func (bc *buildCache) remove(path string) {
+	bc.mu.Lock()
+	delete(bc.Files, bc.key(path))
+	bc.mu.Unlock()
+}
+
+//
+// save the manifest into file.
+//
+func (bc *buildCache) save() (err error) {
+	bc.mu.Lock()
+	b, err := json.MarshalIndent(bc, "", "\t")
+	bc.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("buildCache.save: %w", err)
+	}
+
+	err = os.MkdirAll(bc.dir, 0755)
+	if err != nil {
+		return fmt.Errorf("buildCache.save: %w", err)
+	}
+
+	err = ioutil.WriteFile(bc.path(), b, 0600)
+	if err != nil {
+		return fmt.Errorf("buildCache.save: %w", err)
+	}
+
+	return nil
+}
+
+//
+// set the hash and page metadata of markup file in "path".
+//
+func (bc *buildCache) set(path, hash string, page pageInfo) {
+	bc.mu.Lock()
+	bc.Files[bc.key(path)] = &buildCacheEntry{
+		Hash:     hash,
+		pageInfo: page,
+	}
+	bc.mu.Unlock()
+}
+
+//
+// setGenerated replace the list of generated files with "files", and return
+// the files from the previous list that does not exist in "files".
+//
+func (bc *buildCache) setGenerated(files []string) (stales []string) {
+	current := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		current[file] = struct{}{}
+	}
+
+	bc.mu.Lock()
+	for _, file := range bc.Generated {
+		_, ok := current[file]
+		if !ok {
+			stales = append(stales, file)
+		}
+	}
+	bc.Generated = files
+	bc.mu.Unlock()
+
+	return stales
+}
+
+//
+// setNav set the hash of navigation tree.
+// It will return true if the hash is different with the current cache.
+//
+func (bc *buildCache) setNav(navHash string) (isChanged bool) {
+	bc.mu.Lock()
+	isChanged = bc.Nav != navHash
+	bc.Nav = navHash
+	bc.mu.Unlock()
+	return isChanged
+}
+
+//
+// setOptions set the hash of options that affect the generated HTML files.
+// If the hash is different with the current cache, all the cached entries
+// will be invalidated.
+//
+func (bc *buildCache) setOptions(optsHash string) {
+	bc.mu.Lock()
+	if bc.Options != optsHash {
+		bc.Options = optsHash
+		bc.Files = make(map[string]*buildCacheEntry)
+	}
+	bc.mu.Unlock()
+}
+
+//
+// setSearchURL set the URL of search page.
+// If the URL is different with the current cache, all the cached entries
+// will be invalidated.
+//
+func (bc *buildCache) setSearchURL(searchURL string) {
+	bc.mu.Lock()
+	if bc.Search != searchURL {
+		bc.Search = searchURL
+		bc.Files = make(map[string]*buildCacheEntry)
+	}
+	bc.mu.Unlock()
+}
+
+//
+// setTemplate set the hash of HTML template.
+// If the ciigo version or the template hash is different with the current
+// cache, all the cached entries will be invalidated.
+//
+func (bc *buildCache) setTemplate(tmplContent string) {
+	tmplHash := contentHash([]byte(tmplContent))
+
+	bc.mu.Lock()
+	if bc.Version != Version || bc.Template != tmplHash || bc.Files == nil {
+		bc.Version = Version
+		bc.Template = tmplHash
+		bc.Files = make(map[string]*buildCacheEntry)
+	}
+	bc.mu.Unlock()
+}
@@ -0,0 +1,50 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+func TestBuildCache_setOptions(t *testing.T) {
+	base := optionsHash(&ConvertOptions{})
+
+	cases := []struct {
+		desc      string
+		opts      *ConvertOptions
+		isChanged bool
+	}{{
+		desc: "With the same options",
+		opts: &ConvertOptions{},
+	}, {
+		desc:      "With Drafts",
+		opts:      &ConvertOptions{Drafts: true},
+		isChanged: true,
+	}, {
+		desc: "With MarkdownExtensions",
+		opts: &ConvertOptions{
+			MarkdownExtensions: []goldmark.Extender{extension.Table},
+		},
+		isChanged: true,
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		bc := &buildCache{root: "."}
+		bc.setTemplate("")
+		bc.setOptions(base)
+		bc.set("index.adoc", "hash", pageInfo{})
+
+		bc.setOptions(optionsHash(c.opts))
+
+		test.Assert(t, "is invalidated", c.isChanged,
+			bc.Files["index.adoc"] == nil, true)
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+//nolint: gochecknoglobals
+var (
+	linkAttrRE = regexp.MustCompile(
+		`(?i)<(?:a|img|link|script|iframe|source)\s[^>]*?\b(?:href|src)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	anchorAttrRE = regexp.MustCompile(
+		`(?i)\s(?:id|name)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+//
+// BrokenLink contains the link in the generated HTML file that point to
+// file or anchor that does not exist.
+//
+type BrokenLink struct {
+	// File is the path to the markup file that contains the link, or
+	// the path to the HTML file if the link is not written in markup
+	// file, for example the link in the HTML template.
+	File string
+
+	// Line is the line number of the link inside the File, start from
+	// 1.
+	Line int
+
+	// Link is the value of "href" or "src" attribute.
+	Link string
+
+	// Reason describe why the link is broken.
+	Reason string
+}
+
+//
+// String return the broken link as "file:line: link: reason".
+//
+func (bl *BrokenLink) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", bl.File, bl.Line, bl.Link, bl.Reason)
+}
+
+//
+// linkChecker contains the state for checking links in HTML files.
+//
+type linkChecker struct {
+	opts *ConvertOptions
+
+	// dir is the directory that contains the generated HTML files.
+	dir string
+
+	// sources map the path of generated HTML file to its markup file.
+	sources map[string]*fileMarkup
+
+	// anchors contains the IDs and names of elements in each HTML file.
+	anchors map[string]map[string]struct{}
+
+	brokens []*BrokenLink
+}
+
+//
+// CheckLinks check the links in all HTML files inside the directory "dir",
+// recursively, and return the links that point to file or anchor that does
+// not exist.
+//
+// See CheckLinksWithOptions for more information.
+//
+func CheckLinks(dir string) (brokens []*BrokenLink, err error) {
+	opts := &ConvertOptions{
+		Root: dir,
+	}
+	return CheckLinksWithOptions(opts)
+}
+
+//
+// CheckLinksWithOptions check the links in all HTML files generated from
+// opts.Root, inside the opts.OutputDir if its set or inside the opts.Root,
+// and return the links that point to file or anchor that does not exist.
+//
+// The absolute link, for example "/sub/index.html", is resolved against
+// the output directory, and the relative link is resolved against the
+// directory of HTML file.
+// A link to directory is resolved to its "index.html".
+// The link with scheme or host, for example "https://example.com", and the
+// link to internal endpoint of ciigo server are not checked.
+//
+// The broken link is reported using the line number in the markup file, if
+// the link is written in the markup file, or using the line number in the
+// HTML file.
+// The line in markup file is found on best-effort basis, by matching the
+// occurrences of the same link in order: the Nth occurrence of the link in
+// the HTML file is reported at the Nth occurrence of the link in the markup
+// file.
+// The link that is written differently in the markup file, or that is
+// also written in the HTML template, may be reported at the wrong line.
+//
+func CheckLinksWithOptions(opts *ConvertOptions) (brokens []*BrokenLink, err error) {
+	if opts == nil {
+		opts = &ConvertOptions{}
+	}
+
+	err = opts.init()
+	if err != nil {
+		return nil, fmt.Errorf("ciigo.CheckLinks: %w", err)
+	}
+
+	fileMarkups, err := listFileMarkups(opts.Root, opts)
+	if err != nil {
+		return nil, fmt.Errorf("ciigo.CheckLinks: %w", err)
+	}
+
+	lc := &linkChecker{
+		opts:    opts,
+		dir:     opts.cacheDir(),
+		sources: make(map[string]*fileMarkup, len(fileMarkups)),
+		anchors: make(map[string]map[string]struct{}),
+	}
+	for _, fmarkup := range fileMarkups {
+		lc.sources[filepath.Clean(fmarkup.htmlPath)] = fmarkup
+	}
+
+	err = filepath.Walk(lc.dir, lc.walk)
+	if err != nil {
+		return nil, fmt.Errorf("ciigo.CheckLinks: %w", err)
+	}
+
+	return lc.brokens, nil
+}
+
+//
+// walk check the links in each HTML file, skipping the hidden and excluded
+// files.
+//
+func (lc *linkChecker) walk(file string, fi os.FileInfo, err error) error {
+	if err != nil {
+		return err
+	}
+
+	name := fi.Name()
+	if file != lc.dir && (name[0] == '.' || lc.opts.isExcluded(file)) {
+		if fi.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+	if fi.IsDir() || !strings.EqualFold(filepath.Ext(name), ".html") {
+		return nil
+	}
+
+	return lc.checkFile(file)
+}
+
+//
+// checkFile check all links inside the HTML file.
+//
+func (lc *linkChecker) checkFile(file string) (err error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var (
+		markup []byte
+
+		// offsets contains the position in markup, after the last
+		// occurrence of each link that has been reported.
+		offsets = make(map[string]int)
+	)
+
+	for _, m := range linkAttrRE.FindAllSubmatchIndex(content, -1) {
+		start, end := m[2], m[3]
+		if start < 0 {
+			start, end = m[4], m[5]
+		}
+		link := html.UnescapeString(string(content[start:end]))
+
+		reason := lc.check(file, link)
+		if len(reason) == 0 {
+			continue
+		}
+
+		bl := &BrokenLink{
+			File:   file,
+			Line:   1 + bytes.Count(content[:start], []byte{'\n'}),
+			Link:   link,
+			Reason: reason,
+		}
+
+		fmarkup := lc.sources[filepath.Clean(file)]
+		if fmarkup != nil {
+			if markup == nil {
+				markup, err = ioutil.ReadFile(fmarkup.path)
+				if err != nil {
+					return err
+				}
+			}
+			off := offsets[link]
+			x := bytes.Index(markup[off:], []byte(link))
+			if x >= 0 {
+				x += off
+				offsets[link] = x + len(link)
+				bl.File = fmarkup.path
+				bl.Line = 1 + bytes.Count(markup[:x], []byte{'\n'})
+			}
+		}
+
+		lc.brokens = append(lc.brokens, bl)
+	}
+
+	return nil
+}
+
+//
+// check the link inside the HTML file.
+// It will return the reason if the link is broken, or empty string if the
+// link is valid or not checked.
+//
+func (lc *linkChecker) check(file, link string) (reason string) {
+	if len(link) == 0 || strings.HasPrefix(link, "//") {
+		return ""
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "invalid URL"
+	}
+	if len(u.Scheme) > 0 || len(u.Host) > 0 || len(u.Opaque) > 0 {
+		return ""
+	}
+
+	target := file
+
+	if len(u.Path) > 0 {
+		if strings.HasPrefix(u.Path, "/_internal/") {
+			return ""
+		}
+
+		if path.IsAbs(u.Path) {
+			target = filepath.Join(lc.dir, filepath.FromSlash(u.Path))
+		} else {
+			target = filepath.Join(filepath.Dir(file),
+				filepath.FromSlash(u.Path))
+		}
+
+		fi, err := os.Stat(target)
+		if err != nil {
+			return "file not found"
+		}
+		if fi.IsDir() {
+			target = filepath.Join(target, "index.html")
+			_, err = os.Stat(target)
+			if err != nil {
+				return "directory index not found"
+			}
+		}
+	}
+
+	if len(u.Fragment) == 0 {
+		return ""
+	}
+	if !strings.EqualFold(filepath.Ext(target), ".html") {
+		return ""
+	}
+
+	anchors, err := lc.anchorsOf(target)
+	if err != nil {
+		return err.Error()
+	}
+
+	_, ok := anchors[u.Fragment]
+	if !ok {
+		return "anchor not found"
+	}
+
+	return ""
+}
+
+//
+// anchorsOf return the IDs and names of elements inside the HTML file.
+//
+func (lc *linkChecker) anchorsOf(file string) (
+	anchors map[string]struct{}, err error,
+) {
+	anchors, ok := lc.anchors[file]
+	if ok {
+		return anchors, nil
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	anchors = make(map[string]struct{})
+	for _, m := range anchorAttrRE.FindAllSubmatch(content, -1) {
+		id := m[1]
+		if id == nil {
+			id = m[2]
+		}
+		anchors[html.UnescapeString(string(id))] = struct{}{}
+	}
+
+	lc.anchors[file] = anchors
+
+	return anchors, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestCheckLinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ciigo-checklinks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	markup := "= Title\n\n" +
+		"link:missing.html[First]\n\n" +
+		"link:exist.html[Exist]\n\n" +
+		"link:missing.html[Second]\n"
+
+	htmlContent := "<html><body>\n" +
+		"<p><a href=\"missing.html\">First</a></p>\n" +
+		"<p><a href=\"exist.html\">Exist</a></p>\n" +
+		"<p><a href=\"missing.html\">Second</a></p>\n" +
+		"<p><a href=\"missing.html\">Template</a></p>\n" +
+		"</body></html>\n"
+
+	files := map[string]string{
+		"index.adoc": markup,
+		"index.html": htmlContent,
+		"exist.html": "<html></html>\n",
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	brokens, err := CheckLinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fmarkup := filepath.Join(dir, "index.adoc")
+	fhtml := filepath.Join(dir, "index.html")
+
+	exp := []string{
+		fmarkup + ":3: missing.html: file not found",
+		fmarkup + ":7: missing.html: file not found",
+		fhtml + ":5: missing.html: file not found",
+	}
+
+	got := make([]string, 0, len(brokens))
+	for _, bl := range brokens {
+		got = append(got, bl.String())
+	}
+
+	test.Assert(t, "brokens", exp, got, true)
+}
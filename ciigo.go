@@ -4,7 +4,7 @@
 
 //
 // Package ciigo is a program to write static web server with embedded files
-// using asciidoc and markdown markup languages.
+// using asciidoc, markdown, and Org markup languages.
 //
 // For more information see the README file at the page repository
 // https://github.com/shuLhan/ciigo.
@@ -12,71 +12,150 @@
 package ciigo
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/shuLhan/share/lib/debug"
 	"github.com/shuLhan/share/lib/memfs"
 )
 
+//
+// Version of this library and program.
+// It is recorded in the build cache, so any HTML files generated by
+// different version will be regenerated.
+//
+const Version = "0.3.0"
+
 const (
-	defAddress  = ":8080"
-	defDir      = "."
-	dirAssets   = "assets"
-	extAsciidoc = ".adoc"
-	extMarkdown = ".md"
+	defAddress        = ":8080"
+	defDir            = "."
+	defGenGoFileName  = "ciigo_static.go"
+	defGenPackageName = "main"
+	dirAssets         = "assets"
+	extAsciidoc       = ".adoc"
+	extMarkdown       = ".md"
+	extOrg            = ".org"
+	fileBuildCache    = ".ciigo-cache"
 )
 
 const (
 	metadataAuthor     = "author"
 	metadataDate       = "date"
+	metadataDraft      = "draft"
 	metadataStylesheet = "stylesheet"
 	metadataTitle      = "title"
 )
 
-const (
-	markupKindUnknown byte = iota
-	markupKindAsciidoc
-	markupKindMarkdown
-)
-
-//nolint: gochecknoglobals
-var (
-	defExcludes = []string{
-		`.*\.adoc$`,
-		`.*\.md$`,
+//
+// defaultExcludes return list of regular expressions to exclude markup
+// files, the build cache, the navigation tree, the search index, and hidden
+// files from being served or embedded.
+//
+func defaultExcludes() (excludes []string) {
+	excludes = markupPatterns()
+	excludes = append(excludes,
+		`.*\.ciigo-cache$`,
+		`.*\.ciigo-navigation\.json$`,
+		`.*\.ciigo-search-index\.json$`,
 		`^\..*`,
-	}
-)
+	)
+	return excludes
+}
 
 //
 // Convert all markup files inside directory "dir" recursively into HTML
 // files using "htmlTemplate" file as template.
+//
+// Only markup files that has been changed since the last conversion will
+// be converted, see ConvertWithOptions for more information.
+//
 // If htmlTemplate is empty it will default to use embedded HTML template.
 // See template_index_html.go for template format.
 //
-func Convert(dir, htmlTemplate string) {
-	if len(dir) == 0 {
-		dir = "."
+func Convert(dir, htmlTemplate string) (err error) {
+	opts := &ConvertOptions{
+		Root:         dir,
+		HTMLTemplate: htmlTemplate,
+	}
+	return ConvertWithOptions(opts)
+}
+
+//
+// ConvertWithOptions convert all markup files inside the opts.Root directory
+// recursively into HTML files.
+//
+// The hash of each markup file, the HTML template, and the ciigo Version
+// are recorded in file ".ciigo-cache" inside the opts.Root directory.
+// On the next conversion, only the markup files whose content has been
+// changed, or whose HTML file does not exist, will be converted.
+// Changing the HTML template, the Drafts option, the type of
+// MarkdownExtensions or registered Converter, or upgrading ciigo will
+// convert all of the markup files.
+//
+func ConvertWithOptions(opts *ConvertOptions) (err error) {
+	if opts == nil {
+		opts = &ConvertOptions{}
+	}
+
+	err = opts.init()
+	if err != nil {
+		return fmt.Errorf("ciigo.Convert: %w", err)
+	}
+
+	contentHTML, err := loadHTMLTemplate(opts.HTMLTemplate)
+	if err != nil {
+		return fmt.Errorf("ciigo.Convert: %w", err)
+	}
+
+	layouts, err := loadLayouts(opts.TemplateDir)
+	if err != nil {
+		return fmt.Errorf("ciigo.Convert: %w", err)
+	}
+
+	htmlg, err := newHTMLGenerator(opts, contentHTML, layouts)
+	if err != nil {
+		return fmt.Errorf("ciigo.Convert: %w", err)
 	}
 
-	contentHTML := templateIndexHTML
+	fileMarkups, err := listFileMarkups(opts.Root, opts)
+	if err != nil {
+		return fmt.Errorf("ciigo.Convert: %w", err)
+	}
 
-	if len(htmlTemplate) > 0 {
-		b, err := ioutil.ReadFile(htmlTemplate)
+	if len(opts.OutputDir) > 0 {
+		err = htmlg.copyFiles(opts.Root, opts, fileMarkups)
 		if err != nil {
-			log.Fatal("ciigo.Convert: " + err.Error())
+			return fmt.Errorf("ciigo.Convert: %w", err)
 		}
-		contentHTML = string(b)
 	}
 
-	htmlg := newHTMLGenerator(htmlTemplate, contentHTML)
+	_, err = htmlg.convertFileMarkups(fileMarkups, false)
+	if err != nil {
+		return err
+	}
+
+	published := htmlg.published(fileMarkups)
+
+	if len(opts.BaseURL) > 0 {
+		err = writeSitemap(opts.cacheDir(), opts.Root, opts.BaseURL,
+			published)
+		if err != nil {
+			return fmt.Errorf("ciigo.Convert: %w", err)
+		}
+	}
 
-	fileMarkups := listFileMarkups(dir)
+	err = writeFeeds(opts.cacheDir(), opts.Root, opts.BaseURL, opts.Feeds,
+		published)
+	if err != nil {
+		return fmt.Errorf("ciigo.Convert: %w", err)
+	}
 
-	htmlg.convertFileMarkups(fileMarkups, true)
+	return nil
 }
 
 //
@@ -89,83 +168,224 @@ func Convert(dir, htmlTemplate string) {
 // If htmlTemplate is empty it will default to use embedded HTML template.
 // See template_index_html.go for template format.
 //
-func Generate(dir, out, htmlTemplate string) {
-	contentHTML := templateIndexHTML
+func Generate(dir, out, htmlTemplate string) (err error) {
+	opts := &GenerateOptions{
+		ConvertOptions: ConvertOptions{
+			Root:         dir,
+			HTMLTemplate: htmlTemplate,
+		},
+		GenGoFileName: out,
+	}
+	return GenerateWithOptions(opts)
+}
+
+//
+// GenerateWithOptions convert all markup files inside the opts.Root
+// directory into HTML files, recursively; and then embed all the files
+// inside the opts.Root, except the markup files, into Go file
+// opts.GenGoFileName.
+//
+func GenerateWithOptions(opts *GenerateOptions) (err error) {
+	if opts == nil {
+		opts = &GenerateOptions{}
+	}
+
+	err = opts.init()
+	if err != nil {
+		return fmt.Errorf("ciigo.Generate: %w", err)
+	}
+
+	contentHTML, err := loadHTMLTemplate(opts.HTMLTemplate)
+	if err != nil {
+		return fmt.Errorf("ciigo.Generate: %w", err)
+	}
+
+	layouts, err := loadLayouts(opts.TemplateDir)
+	if err != nil {
+		return fmt.Errorf("ciigo.Generate: %w", err)
+	}
+
+	htmlg, err := newHTMLGenerator(&opts.ConvertOptions, contentHTML,
+		layouts)
+	if err != nil {
+		return fmt.Errorf("ciigo.Generate: %w", err)
+	}
 
-	if len(htmlTemplate) > 0 {
-		b, err := ioutil.ReadFile(htmlTemplate)
+	fileMarkups, err := listFileMarkups(opts.Root, &opts.ConvertOptions)
+	if err != nil {
+		return fmt.Errorf("ciigo.Generate: %w", err)
+	}
+
+	dir := opts.Root
+	if len(opts.OutputDir) > 0 {
+		err = htmlg.copyFiles(opts.Root, &opts.ConvertOptions, fileMarkups)
+		if err != nil {
+			return fmt.Errorf("ciigo.Generate: %w", err)
+		}
+		dir = opts.OutputDir
+	}
+
+	_, err = htmlg.convertFileMarkups(fileMarkups, false)
+	if err != nil {
+		return err
+	}
+
+	published := htmlg.published(fileMarkups)
+
+	if len(opts.BaseURL) > 0 {
+		err = writeSitemap(dir, opts.Root, opts.BaseURL, published)
 		if err != nil {
-			log.Fatal("ciigo.Generate: " + err.Error())
+			return fmt.Errorf("ciigo.Generate: %w", err)
 		}
-		contentHTML = string(b)
 	}
 
-	htmlg := newHTMLGenerator(htmlTemplate, contentHTML)
-	fileMarkups := listFileMarkups(dir)
+	err = writeFeeds(dir, opts.Root, opts.BaseURL, opts.Feeds, published)
+	if err != nil {
+		return fmt.Errorf("ciigo.Generate: %w", err)
+	}
 
-	htmlg.convertFileMarkups(fileMarkups, len(htmlTemplate) == 0)
+	excludes := append(defaultExcludes(), opts.Exclude...)
 
-	mfs, err := memfs.New(dir, nil, defExcludes, true)
+	mfs, err := memfs.New(dir, nil, excludes, true)
 	if err != nil {
-		log.Fatal("ciigo.Generate: " + err.Error())
+		return fmt.Errorf("ciigo.Generate: %w", err)
+	}
+
+	if len(opts.HTMLTemplate) > 0 {
+		_, err = mfs.AddFile(opts.HTMLTemplate)
+		if err != nil {
+			return fmt.Errorf("ciigo.Generate: AddFile %s: %w",
+				opts.HTMLTemplate, err)
+		}
+	}
+	if len(opts.SearchTemplate) > 0 {
+		_, err = mfs.AddFile(opts.SearchTemplate)
+		if err != nil {
+			return fmt.Errorf("ciigo.Generate: AddFile %s: %w",
+				opts.SearchTemplate, err)
+		}
 	}
 
-	if len(htmlTemplate) > 0 {
-		_, err = mfs.AddFile(htmlTemplate)
+	// The navigation tree and the search index are embedded outside of
+	// the served files, so the server can load them without exposing
+	// them.
+	for _, name := range []string{fileNavigation, fileSearchIndex} {
+		_, err = mfs.AddFile(filepath.Join(dir, name))
 		if err != nil {
-			log.Fatalf("ciigo.Generate: AddFile %s: %s", htmlTemplate, err.Error())
+			return fmt.Errorf("ciigo.Generate: AddFile %s: %w",
+				name, err)
 		}
 	}
 
-	err = mfs.GoGenerate("", out, memfs.EncodingGzip)
+	layoutFiles, err := listLayouts(opts.TemplateDir)
 	if err != nil {
-		log.Fatal("ciigo.Generate: " + err.Error())
+		return fmt.Errorf("ciigo.Generate: %w", err)
 	}
+	for _, file := range layoutFiles {
+		_, err = mfs.AddFile(file)
+		if err != nil {
+			return fmt.Errorf("ciigo.Generate: AddFile %s: %w", file, err)
+		}
+	}
+
+	err = mfs.GoGenerate(opts.GenPackageName, opts.GenGoFileName,
+		memfs.EncodingGzip)
+	if err != nil {
+		return fmt.Errorf("ciigo.Generate: %w", err)
+	}
+
+	return nil
 }
 
 //
 // Serve the content at directory "dir" using HTTP server at specific
 // "address".
 //
-func Serve(dir, address, htmlTemplate string) {
-	if len(dir) == 0 {
-		dir = defDir
-	}
-	if len(address) == 0 {
-		address = defAddress
+func Serve(dir, address, htmlTemplate string) (err error) {
+	opts := &ServeOptions{
+		ConvertOptions: ConvertOptions{
+			Root:         dir,
+			HTMLTemplate: htmlTemplate,
+		},
+		Address:       address,
+		IsDevelopment: debug.Value > 0,
 	}
-	srv := newServer(dir, address, htmlTemplate)
-	srv.start()
+	return ServeWithOptions(opts)
+}
+
+//
+// ServeWithOptions serve the content inside the opts.Root directory using
+// HTTP server at opts.Address.
+//
+func ServeWithOptions(opts *ServeOptions) (err error) {
+	return ServeContext(context.Background(), opts)
 }
 
-func isExtensionMarkup(ext string) bool {
-	return ext == extAsciidoc || ext == extMarkdown
+//
+// ServeContext serve the content inside the opts.Root directory using HTTP
+// server at opts.Address, until the ctx is done.
+//
+// When the ctx is done, the server stop watching changes on markup files
+// and HTML template, wait for in-flight requests to finish, and then
+// return.
+//
+func ServeContext(ctx context.Context, opts *ServeOptions) (err error) {
+	if opts == nil {
+		opts = &ServeOptions{}
+	}
+
+	err = opts.init()
+	if err != nil {
+		return fmt.Errorf("ciigo.Serve: %w", err)
+	}
+
+	srv, err := newServer(opts)
+	if err != nil {
+		return fmt.Errorf("ciigo.Serve: %w", err)
+	}
+
+	return srv.start(ctx)
 }
 
 //
 // listFileMarkups find any markup files inside the content directory,
 // recursively.
+// The returned list is sorted by path, directory by directory.
 //
-func listFileMarkups(dir string) (fileMarkups []*fileMarkup) {
+func listFileMarkups(dir string, opts *ConvertOptions) (
+	fileMarkups []*fileMarkup, err error,
+) {
 	d, err := os.Open(dir)
 	if err != nil {
-		log.Fatal("ciigo: listFileMarkups: os.Open: ", err)
+		return nil, fmt.Errorf("listFileMarkups: %w", err)
 	}
 
 	fis, err := d.Readdir(0)
+	_ = d.Close()
 	if err != nil {
-		log.Fatal("generate: " + err.Error())
+		return nil, fmt.Errorf("listFileMarkups: %w", err)
 	}
 
+	sort.Slice(fis, func(x, y int) bool {
+		return fis[x].Name() < fis[y].Name()
+	})
+
 	for _, fi := range fis {
 		name := fi.Name()
+		filePath := filepath.Join(dir, name)
 
 		if name == dirAssets {
 			continue
 		}
+		if opts.isExcluded(filePath) {
+			continue
+		}
 		if fi.IsDir() && name[0] != '.' {
-			newdir := filepath.Join(dir, fi.Name())
-			fileMarkups = append(fileMarkups, listFileMarkups(newdir)...)
+			fmarkups, err := listFileMarkups(filePath, opts)
+			if err != nil {
+				return nil, err
+			}
+			fileMarkups = append(fileMarkups, fmarkups...)
 			continue
 		}
 
@@ -178,23 +398,127 @@ func listFileMarkups(dir string) (fileMarkups []*fileMarkup) {
 		}
 
 		markupf := &fileMarkup{
-			kind:     markupKind(ext),
-			path:     filepath.Join(dir, name),
+			ext:      ext,
+			path:     filePath,
 			info:     fi,
 			basePath: filepath.Join(dir, strings.TrimSuffix(name, ext)),
 		}
+		markupf.htmlPath = opts.htmlPath(markupf.basePath)
 		fileMarkups = append(fileMarkups, markupf)
 	}
 
-	return fileMarkups
+	return fileMarkups, nil
+}
+
+//
+// copyFiles copy all non-markup files inside the directory "dir" into the
+// opts.OutputDir, recursively, except the excluded files, the templates,
+// and the files whose path is the same as the generated HTML files.
+// The file is copied only if its size or modification time is different
+// with the existing file in the output directory.
+//
+func (htmlg *htmlGenerator) copyFiles(
+	dir string, opts *ConvertOptions, fileMarkups []*fileMarkup,
+) (err error) {
+	htmlPaths := make(map[string]struct{}, len(fileMarkups))
+	for _, fmarkup := range fileMarkups {
+		htmlPaths[fmarkup.htmlPath] = struct{}{}
+	}
+
+	return htmlg.copyDir(dir, opts, htmlPaths)
 }
 
-func markupKind(ext string) byte {
-	switch ext {
-	case extAsciidoc:
-		return markupKindAsciidoc
-	case extMarkdown:
-		return markupKindMarkdown
+func (htmlg *htmlGenerator) copyDir(
+	dir string, opts *ConvertOptions, htmlPaths map[string]struct{},
+) (err error) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("copyFiles: %w", err)
+	}
+
+	fis, err := d.Readdir(0)
+	_ = d.Close()
+	if err != nil {
+		return fmt.Errorf("copyFiles: %w", err)
 	}
-	return markupKindUnknown
+
+	for _, fi := range fis {
+		name := fi.Name()
+		filePath := filepath.Join(dir, name)
+
+		if name[0] == '.' || opts.isExcluded(filePath) {
+			continue
+		}
+		if fi.IsDir() {
+			err = htmlg.copyDir(filePath, opts, htmlPaths)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			// Copy the file that the symbolic link point to.
+			// The broken link and the link to directory are
+			// skipped.
+			fi, err = os.Stat(filePath)
+			if err != nil {
+				continue
+			}
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		if filePath == filepath.Clean(opts.HTMLTemplate) ||
+			htmlg.isInTemplateDir(filePath) {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(name))
+		if isExtensionMarkup(ext) {
+			continue
+		}
+
+		outPath := opts.outputPath(filePath)
+		if _, ok := htmlPaths[outPath]; ok {
+			continue
+		}
+
+		err = copyFile(filePath, outPath, fi)
+		if err != nil {
+			return fmt.Errorf("copyFiles: %w", err)
+		}
+	}
+
+	return nil
+}
+
+//
+// copyFile copy the file from src to dst, only if the dst does not exist or
+// its size and modification time is different with src.
+//
+func copyFile(src, dst string, srcInfo os.FileInfo) (err error) {
+	dstInfo, err := os.Stat(dst)
+	if err == nil {
+		if dstInfo.Size() == srcInfo.Size() &&
+			dstInfo.ModTime().Equal(srcInfo.ModTime()) {
+			return nil
+		}
+	}
+
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(dst, b, srcInfo.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	return os.Chtimes(dst, srcInfo.ModTime(), srcInfo.ModTime())
 }
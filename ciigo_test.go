@@ -0,0 +1,92 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestHTMLGenerator_copyFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "ciigo-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	outDir, err := ioutil.TempDir("", "ciigo-out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+
+	files := []string{
+		"index.adoc",
+		"style.css",
+		filepath.Join("templates", "_header.tmpl"),
+		filepath.Join("templates", "blog.tmpl"),
+		filepath.Join("templates", "html.tmpl"),
+		filepath.Join("sub", "logo.png"),
+	}
+	for _, file := range files {
+		file = filepath.Join(root, file)
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(file, []byte(file), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &ConvertOptions{
+		Root:         root,
+		OutputDir:    outDir,
+		TemplateDir:  filepath.Join(root, "templates"),
+		HTMLTemplate: filepath.Join(root, "templates", "html.tmpl"),
+	}
+	err = opts.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	htmlg := &htmlGenerator{
+		templateDir: opts.TemplateDir,
+	}
+
+	err = htmlg.copyFiles(root, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err = filepath.Walk(outDir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			rel, _ := filepath.Rel(outDir, path)
+			got = append(got, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+
+	exp := []string{
+		"style.css",
+		filepath.Join("sub", "logo.png"),
+	}
+
+	test.Assert(t, "copied files", exp, got, true)
+}
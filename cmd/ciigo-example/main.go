@@ -9,9 +9,14 @@
 package main
 
 import (
+	"log"
+
 	"github.com/shuLhan/ciigo"
 )
 
 func main() {
-	ciigo.Serve("_example", ":8080", "_example/html.tmpl")
+	err := ciigo.Serve("_example", ":8080", "_example/html.tmpl")
+	if err != nil {
+		log.Fatal(err)
+	}
 }
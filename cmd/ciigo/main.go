@@ -10,32 +10,76 @@
 //
 // The following section describe how to use ciigo CLI.
 //
-//	ciigo [-template <file>] convert <dir>
+//	ciigo [-template <file>] [-template-dir <dir>] [-exclude <regex>]
+//		[-output-dir <dir>] [-base-url <url>] [-feeds <dirs>]
+//		[-static-search] convert <dir>
 //
-// Scan the "dir" recursively to find markup files (.adoc or .md) and convert
-// them into HTML files.
+// Scan the "dir" recursively to find markup files (.adoc, .md, or .org) and
+// convert them into HTML files.
 // The template "file" is optional, default to embedded HTML template.
+// The "template-dir" is optional, the directory of layout templates
+// "<name>.tmpl", that can be selected by page using metadata
+// "layout: <name>", and partial templates "_<name>.tmpl", that can be
+// included by the HTML template and the layouts as template "<name>".
+// The "exclude" regex is optional, any path that match with it will be
+// ignored.
+// The "output-dir" is optional, if its set the HTML files are written into
+// that directory, along with copy of all non-markup files, instead of next
+// to their markup files.
+// The "base-url" is optional, if its set the file "sitemap.xml" that list
+// all of the pages under the "url" is written into the "dir", or into the
+// "output-dir" if its set.
+// The "feeds" is optional, a comma separated list of directories, relative
+// to "dir", whose dated pages are published as RSS feed "feed.xml" and Atom
+// feed "atom.xml" inside each of them.
+// The "base-url" must be set if "feeds" is set.
+// The "static-search" is optional, if its set the search page, its script,
+// and the search index are written into directory "search", so the pages
+// can be searched without ciigo server.
 //
-//	ciigo [-template <file>] [-out <file>] generate <dir>
+//	ciigo [-template <file>] [-template-dir <dir>] [-search-template <file>]
+//		[-exclude <regex>] [-base-url <url>] [-feeds <dirs>]
+//		[-static-search] [-out <file>] generate <dir>
 //
 // Convert all the markup files inside directory "dir" recursively and then
-// embed them into ".go" source file.
+// embed them, including the "sitemap.xml", feeds, and templates, into ".go"
+// source file.
+// The "search-template" is optional, the template for rendering the search
+// results, default to embedded search template.
 // The output file is optional, default to "ciigo_static.go" in current
 // directory.
 //
-//	ciigo [-template <file>] [-address <ip:port>] serve <dir>
+//	ciigo [-template <file>] [-template-dir <dir>] [-search-template <file>]
+//		[-exclude <regex>] [-base-url <url>] [-feeds <dirs>] [-drafts]
+//		[-address <ip:port>] serve <dir>
 //
 // Serve all files inside directory "dir" using HTTP server, watch changes on
-// markup files and convert them to HTML files.
+// markup files and convert them to HTML files, and update the feeds.
+// Any changes on the HTML template, layout templates, or search template
+// are reloaded.
+// The "drafts" is optional, if its set the draft pages and the pages with
+// date in the future are published too.
 // If the address is not set, its default to ":8080".
 //
+//	ciigo [-exclude <regex>] [-output-dir <dir>] check-links <dir>
+//
+// Check the links in all HTML files generated from directory "dir", or
+// inside the "output-dir" if its set, and print the links that point to
+// file or anchor that does not exist, with the markup file and line number
+// that contains them.
+// It will exit with non-zero status if at least one broken link is found.
+//
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/shuLhan/ciigo"
 	"github.com/shuLhan/share/lib/debug"
@@ -45,6 +89,22 @@ func main() {
 	isHelp := flag.Bool("help", false, "print help")
 
 	htmlTemplate := flag.String("template", "", "path to HTML template")
+	templateDir := flag.String("template-dir", "",
+		"path to directory of layout templates")
+	searchTemplate := flag.String("search-template", "",
+		"path to template for search results")
+	exclude := flag.String("exclude", "",
+		"a regex to exclude certain paths from being scanned")
+	outputDir := flag.String("output-dir", "",
+		"path to directory where the HTML files are written")
+	baseURL := flag.String("base-url", "",
+		"the URL where the site is published, to generate sitemap.xml")
+	feeds := flag.String("feeds", "",
+		"comma separated list of directories to generate RSS and Atom feeds")
+	staticSearch := flag.Bool("static-search", false,
+		"generate search page and index that works without ciigo server")
+	drafts := flag.Bool("drafts", false,
+		"include draft and future pages")
 	outputFile := flag.String("out", "ciigo_static.go",
 		"path to output of .go generated file")
 	address := flag.String("address", ":8080",
@@ -68,19 +128,94 @@ func main() {
 		dir = "."
 	}
 
+	convertOpts := ciigo.ConvertOptions{
+		Root:         dir,
+		HTMLTemplate: *htmlTemplate,
+		TemplateDir:  *templateDir,
+		OutputDir:    *outputDir,
+		BaseURL:      *baseURL,
+		StaticSearch: *staticSearch,
+		Drafts:       *drafts,
+
+		SearchTemplate: *searchTemplate,
+	}
+	if len(*exclude) > 0 {
+		convertOpts.Exclude = []string{*exclude}
+	}
+	if len(*feeds) > 0 {
+		convertOpts.Feeds = strings.Split(*feeds, ",")
+	}
+
+	var err error
+
 	command = strings.ToLower(command)
 	switch command {
 	case "convert":
-		ciigo.Convert(dir, *htmlTemplate)
+		err = ciigo.ConvertWithOptions(&convertOpts)
 	case "generate":
-		ciigo.Generate(dir, *outputFile, *htmlTemplate)
+		genOpts := &ciigo.GenerateOptions{
+			ConvertOptions: convertOpts,
+			GenGoFileName:  *outputFile,
+		}
+		err = ciigo.GenerateWithOptions(genOpts)
 	case "serve":
+		if len(*outputDir) > 0 {
+			log.Println("ciigo: flag -output-dir is ignored by serve")
+		}
 		debug.Value = 2
-		ciigo.Serve(dir, *address, *htmlTemplate)
+		serveOpts := &ciigo.ServeOptions{
+			ConvertOptions: convertOpts,
+			Address:        *address,
+			IsDevelopment:  true,
+		}
+		err = serve(serveOpts)
+	case "check-links":
+		err = checkLinks(&convertOpts)
 	default:
 		usage()
 		os.Exit(1)
 	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+//
+// serve the content until the program receive SIGINT or SIGTERM.
+//
+func serve(opts *ciigo.ServeOptions) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	qsignal := make(chan os.Signal, 1)
+	signal.Notify(qsignal, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-qsignal
+		cancel()
+	}()
+
+	return ciigo.ServeContext(ctx, opts)
+}
+
+//
+// checkLinks print the broken links in the generated HTML files, and return
+// an error if at least one broken link is found.
+//
+func checkLinks(opts *ciigo.ConvertOptions) error {
+	brokens, err := ciigo.CheckLinksWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, bl := range brokens {
+		fmt.Println(bl)
+	}
+
+	if len(brokens) > 0 {
+		return fmt.Errorf("found %d broken link(s)", len(brokens))
+	}
+
+	return nil
 }
 
 func usage() {
@@ -92,22 +227,64 @@ files, as HTML files.
 
 ==  Usage
 
-ciigo [-template <file>] convert <dir>
+ciigo [-template <file>] [-template-dir <dir>] [-exclude <regex>]
+	[-output-dir <dir>] [-base-url <url>] [-feeds <dirs>]
+	[-static-search] convert <dir>
 
-	Scan the "dir" recursively to find markup files (.adoc or .md)
+	Scan the "dir" recursively to find markup files (.adoc, .md, or .org)
 	and convert them into HTML files.
 	The template "file" is optional, default to embedded HTML template.
+	The "template-dir" is optional, the directory of layout templates
+	"<name>.tmpl", that can be selected by page using metadata
+	"layout: <name>", and partial templates "_<name>.tmpl", that can be
+	included by the HTML template and the layouts as template "<name>".
+	The "exclude" regex is optional, any path that match with it will be
+	ignored.
+	The "output-dir" is optional, if its set the HTML files are written
+	into that directory, along with copy of all non-markup files, instead
+	of next to their markup files.
+	The "base-url" is optional, if its set the file "sitemap.xml" that
+	list all of the pages under the "url" is written into the "dir", or
+	into the "output-dir" if its set.
+	The "feeds" is optional, a comma separated list of directories,
+	relative to "dir", whose dated pages are published as RSS feed
+	"feed.xml" and Atom feed "atom.xml" inside each of them.
+	The "base-url" must be set if "feeds" is set.
+	The "static-search" is optional, if its set the search page, its
+	script, and the search index are written into directory "search", so
+	the pages can be searched without ciigo server.
 
-ciigo [-template <file>] [-out <file>] generate <dir>
+ciigo [-template <file>] [-template-dir <dir>] [-search-template <file>]
+	[-exclude <regex>] [-base-url <url>] [-feeds <dirs>]
+	[-static-search] [-out <file>] generate <dir>
 
 	Convert all markup files inside directory "dir" recursively and then
-	embed them into ".go" source file.
+	embed them, including the "sitemap.xml", feeds, and templates, into
+	".go" source file.
+	The "search-template" is optional, the template for rendering the
+	search results, default to embedded search template.
 	The output file is optional, default to "ciigo_static.go" in current
 	directory.
 
-ciigo [-template <file>] [-address <ip:port>] serve <dir>
+ciigo [-template <file>] [-template-dir <dir>] [-search-template <file>]
+	[-exclude <regex>] [-base-url <url>] [-feeds <dirs>] [-drafts]
+	[-address <ip:port>] serve <dir>
 
 	Serve all files inside directory "dir" using HTTP server, watch
-	changes on markup files and convert them to HTML files automatically.
-	If the address is not set, its default to ":8080".`)
+	changes on markup files and convert them to HTML files automatically,
+	and update the feeds.
+	Any changes on the HTML template, layout templates, or search
+	template are reloaded.
+	The "drafts" is optional, if its set the draft pages and the pages
+	with date in the future are published too.
+	If the address is not set, its default to ":8080".
+
+ciigo [-exclude <regex>] [-output-dir <dir>] check-links <dir>
+
+	Check the links in all HTML files generated from directory "dir", or
+	inside the "output-dir" if its set, and print the links that point
+	to file or anchor that does not exist, with the markup file and line
+	number that contains them.
+	It will exit with non-zero status if at least one broken link is
+	found.`)
 }
@@ -0,0 +1,30 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+//
+// ConvertError define an error when converting a markup file into HTML.
+// The Path field contains the path to markup file that cause the error and
+// the Err field contains the underlying error.
+//
+type ConvertError struct {
+	Path string
+	Err  error
+}
+
+//
+// Error return the string representation of error, prefixed with path to
+// the markup file.
+//
+func (cerr *ConvertError) Error() string {
+	return "ciigo: convert " + cerr.Path + ": " + cerr.Err.Error()
+}
+
+//
+// Unwrap return the underlying error.
+//
+func (cerr *ConvertError) Unwrap() error {
+	return cerr.Err
+}
@@ -0,0 +1,218 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+
+	"github.com/yuin/goldmark"
+)
+
+//
+// ConvertOptions define the options to use on ConvertWithOptions function.
+//
+type ConvertOptions struct {
+	// Root directory where its content will be converted to HTML.
+	// This field is optional, default to current directory.
+	Root string
+
+	// HTMLTemplate define path to the HTML template to be used when
+	// converting markup file into HTML.
+	// This field is optional, if its empty it will default to use
+	// embedded HTML template.
+	// See template_index_html.go for template format.
+	HTMLTemplate string
+
+	// TemplateDir define path to the directory that contains the layout
+	// templates, one template per file with extension ".tmpl".
+	// The page can select the layout using metadata "layout" with the
+	// file name without extension as its value, for example
+	// "layout: blog" for file "blog.tmpl".
+	// The page without "layout" metadata use the HTMLTemplate.
+	// The template whose file name start with "_" is a partial
+	// template, that can be included or inherited by the HTMLTemplate
+	// and the layouts using its name without "_", for example
+	// {{template "header" .}} for file "_header.tmpl", but can not be
+	// selected as layout.
+	// This field is optional.
+	TemplateDir string
+
+	// SearchTemplate define path to the template to be used when
+	// rendering the search results, inside the HTML template, on the
+	// search page of ciigo server.
+	// The template is executed with *SearchResponse as its data.
+	// This field is optional, if its empty it will default to use
+	// embedded search template.
+	// See template_search.go for template format.
+	SearchTemplate string
+
+	// Exclude define list of regular expressions to exclude certain
+	// paths from being scanned.
+	// The regular expression is matched against the file path, including
+	// the Root directory.
+	// This field is optional.
+	Exclude []string
+
+	// MarkdownExtensions define list of goldmark extensions to be used
+	// when converting markdown files, in addition to the default
+	// meta extension.
+	// If its set, it will override the registered Converter for ".md"
+	// files.
+	// This field is optional.
+	MarkdownExtensions []goldmark.Extender
+
+	// OutputDir define the directory where the generated HTML files will
+	// be written, using the same directory structure as in Root.
+	// All non-markup files inside Root, for example images and
+	// stylesheets, are copied into OutputDir too, except the one that
+	// are excluded.
+	// The build cache is stored inside the OutputDir.
+	// If its inside the Root directory, it will be excluded from being
+	// scanned.
+	//
+	// This field is optional, if its empty the HTML file will be written
+	// in the same directory as its markup file.
+	// This field is ignored by ServeWithOptions, the server always
+	// write and serve the HTML files inside the Root.
+	OutputDir string
+
+	// BaseURL define the URL where the site is published, for example
+	// "https://example.com".
+	// If its set, the file "sitemap.xml" that list all of the pages is
+	// written into the root of output directory, either OutputDir or
+	// Root.
+	// This field is optional.
+	BaseURL string
+
+	// Feeds define list of directories, relative to Root, whose pages
+	// are published as RSS 2.0 feed "feed.xml" and Atom feed "atom.xml"
+	// inside the same directory.
+	// Only pages that have valid "date" metadata are published.
+	// The BaseURL must be set, since the link of each page in the feed
+	// is the BaseURL joined with the page path, and the relative link is
+	// not valid in RSS and Atom feeds.
+	// This field is optional.
+	Feeds []string
+
+	// StaticSearch if its true, the search page "search/index.html",
+	// its script "search/search.js", and the search index
+	// "search/index.json" are written into the output directory, so the
+	// pages can be searched without ciigo server, for example when
+	// deployed to static hosting.
+	// The search form in HTML template should use the field
+	// "SearchURL" as its action.
+	// This field is optional.
+	StaticSearch bool
+
+	// Drafts if its true, the draft pages, the one with metadata
+	// "draft: true", and the pages with "date" in the future are
+	// converted and published.
+	// By default, those pages are not converted, and excluded from
+	// search, navigation, sitemap, feeds, and taxonomy pages.
+	// This field is optional.
+	Drafts bool
+
+	// Workers define the number of markup files to be converted
+	// concurrently.
+	// The asciidoc files are parsed one at a time, since libasciidoc is
+	// not safe to be used concurrently, so only the other steps, for
+	// example rendering the HTML template and writing the HTML file, are
+	// run concurrently for them.
+	// This field is optional, default to runtime.GOMAXPROCS.
+	Workers int
+
+	excRE     []*regexp.Regexp
+	outDirAbs string
+}
+
+//
+// init set the default value for each empty field and compile the Exclude
+// patterns.
+//
+func (opts *ConvertOptions) init() (err error) {
+	if len(opts.Root) == 0 {
+		opts.Root = defDir
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+
+	if len(opts.Feeds) > 0 && len(opts.BaseURL) == 0 {
+		return fmt.Errorf("ConvertOptions: Feeds require BaseURL")
+	}
+
+	if len(opts.OutputDir) > 0 {
+		opts.outDirAbs, err = filepath.Abs(opts.OutputDir)
+		if err != nil {
+			return fmt.Errorf("ConvertOptions: %w", err)
+		}
+	}
+
+	opts.excRE = opts.excRE[:0]
+	for _, str := range opts.Exclude {
+		re, err := regexp.Compile(str)
+		if err != nil {
+			return fmt.Errorf("ConvertOptions: %w", err)
+		}
+		opts.excRE = append(opts.excRE, re)
+	}
+
+	return nil
+}
+
+//
+// cacheDir return the directory where the build cache is stored.
+//
+func (opts *ConvertOptions) cacheDir() string {
+	if len(opts.OutputDir) > 0 {
+		return opts.OutputDir
+	}
+	return opts.Root
+}
+
+//
+// htmlPath return the path to the generated HTML file based on path of
+// markup file without extension.
+//
+func (opts *ConvertOptions) htmlPath(basePath string) string {
+	if len(opts.OutputDir) == 0 {
+		return basePath + ".html"
+	}
+	return opts.outputPath(basePath) + ".html"
+}
+
+//
+// outputPath return the path of file inside the OutputDir, based on its
+// path inside the Root directory.
+//
+func (opts *ConvertOptions) outputPath(path string) string {
+	rel, err := filepath.Rel(opts.Root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.Join(opts.OutputDir, rel)
+}
+
+//
+// isExcluded will return true if the path is the OutputDir or match with
+// one of the Exclude patterns.
+//
+func (opts *ConvertOptions) isExcluded(path string) bool {
+	if len(opts.outDirAbs) > 0 {
+		abs, err := filepath.Abs(path)
+		if err == nil && abs == opts.outDirAbs {
+			return true
+		}
+	}
+	for _, re := range opts.excRE {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//
+// Converter define an interface to convert markup content into HTML.
+//
+// The Converter must be safe to be used concurrently, since the markup
+// files are converted by several goroutines.
+//
+type Converter interface {
+	// Extensions return list of file extensions, including the leading
+	// dot, that is handled by the converter, for example ".adoc".
+	Extensions() []string
+
+	// Convert the markup content "in" into HTML body and write it to
+	// "out".
+	// It return the metadata of markup, for example "title", "author",
+	// and "date".
+	Convert(in []byte, out io.Writer) (metadata map[string]interface{}, err error)
+}
+
+//nolint: gochecknoglobals
+var (
+	convertersLock sync.RWMutex
+	converters     = newDefaultConverters()
+)
+
+func newDefaultConverters() map[string]Converter {
+	convs := make(map[string]Converter)
+	for _, conv := range []Converter{
+		newAsciidocConverter(),
+		NewMarkdownConverter(nil),
+		newOrgConverter(),
+	} {
+		for _, ext := range conv.Extensions() {
+			convs[strings.ToLower(ext)] = conv
+		}
+	}
+	return convs
+}
+
+//
+// RegisterConverter register the Converter for each of its extensions.
+// If the extension has been registered before, for example ".md", the
+// previous Converter for that extension will be replaced.
+//
+// The registered extensions are used to find the markup files, to exclude
+// them from being served or embedded, and to watch changes in development
+// mode.
+//
+func RegisterConverter(conv Converter) {
+	if conv == nil {
+		return
+	}
+
+	convertersLock.Lock()
+	for _, ext := range conv.Extensions() {
+		if len(ext) == 0 {
+			continue
+		}
+		converters[strings.ToLower(ext)] = conv
+	}
+	convertersLock.Unlock()
+}
+
+//
+// getConverter return the registered Converter for file extension "ext".
+//
+func getConverter(ext string) (conv Converter) {
+	convertersLock.RLock()
+	conv = converters[strings.ToLower(ext)]
+	convertersLock.RUnlock()
+	return conv
+}
+
+//
+// isExtensionMarkup return true if the file extension "ext" has registered
+// Converter.
+//
+func isExtensionMarkup(ext string) bool {
+	return getConverter(ext) != nil
+}
+
+//
+// markupExtensions return the sorted list of registered extensions.
+//
+func markupExtensions() (exts []string) {
+	convertersLock.RLock()
+	for ext := range converters {
+		exts = append(exts, ext)
+	}
+	convertersLock.RUnlock()
+
+	sort.Strings(exts)
+
+	return exts
+}
+
+//
+// markupPatterns return list of regular expressions that match the
+// registered markup extensions.
+//
+func markupPatterns() (patterns []string) {
+	for _, ext := range markupExtensions() {
+		patterns = append(patterns, `.*`+regexp.QuoteMeta(ext)+`$`)
+	}
+	return patterns
+}
@@ -0,0 +1,51 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"github.com/bytesparadise/libasciidoc"
+	"github.com/bytesparadise/libasciidoc/pkg/types"
+)
+
+//
+// asciidocConverter convert asciidoc markup into HTML using libasciidoc.
+//
+// The call to libasciidoc is serialized, because its use global variable to
+// generate footnote sequence and change the working directory when
+// including files.
+// So, converting asciidoc files concurrently only speed up the other parts
+// of conversion, for example reading the markup file and writing the HTML
+// file.
+//
+type asciidocConverter struct {
+	lock sync.Mutex
+}
+
+func newAsciidocConverter() *asciidocConverter {
+	return &asciidocConverter{}
+}
+
+func (adoc *asciidocConverter) Extensions() []string {
+	return []string{extAsciidoc}
+}
+
+func (adoc *asciidocConverter) Convert(in []byte, out io.Writer) (
+	metadata map[string]interface{}, err error,
+) {
+	adoc.lock.Lock()
+	defer adoc.lock.Unlock()
+
+	// Reset the footnote sequence, so the footnote IDs on each page
+	// start from 1, independent of the order of conversion.
+	types.ResetFootnoteSequence()
+
+	return libasciidoc.ConvertToHTML(context.Background(),
+		bytes.NewReader(in), out)
+}
@@ -0,0 +1,41 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestAsciidocConverter_Convert(t *testing.T) {
+	in := []byte("= Title\n\n" +
+		"First.footnoteref:[note,Note.]\n\n" +
+		"Second.footnoteref:[note]\n")
+	conv := newAsciidocConverter()
+
+	var first bytes.Buffer
+
+	_, err := conv.Convert(in, &first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, "footnote links", 2,
+		strings.Count(first.String(), `href="#_footnotedef_1"`), true)
+
+	// Converting the same document again should generate the same
+	// link to footnote.
+	var second bytes.Buffer
+
+	_, err = conv.Convert(in, &second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, "HTML", first.String(), second.String(), true)
+}
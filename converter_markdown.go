@@ -0,0 +1,62 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"io"
+
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/parser"
+)
+
+//
+// markdownConverter convert markdown markup into HTML using goldmark.
+//
+type markdownConverter struct {
+	md goldmark.Markdown
+}
+
+//
+// NewMarkdownConverter create new Converter for markdown files using the
+// goldmark "md".
+// The metadata is read using the goldmark-meta extension, so the "md"
+// should be created with meta.Meta extension.
+//
+// If "md" is nil, it will default to goldmark with meta.Meta extension and
+// automatic heading ID.
+//
+func NewMarkdownConverter(md goldmark.Markdown) Converter {
+	if md == nil {
+		md = goldmark.New(
+			goldmark.WithExtensions(
+				meta.Meta,
+			),
+			goldmark.WithParserOptions(
+				parser.WithAutoHeadingID(),
+			),
+		)
+	}
+	return &markdownConverter{
+		md: md,
+	}
+}
+
+func (mdc *markdownConverter) Extensions() []string {
+	return []string{extMarkdown}
+}
+
+func (mdc *markdownConverter) Convert(in []byte, out io.Writer) (
+	metadata map[string]interface{}, err error,
+) {
+	ctx := parser.NewContext()
+
+	err = mdc.md.Convert(in, out, parser.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return meta.Get(ctx), nil
+}
@@ -0,0 +1,607 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//nolint: gochecknoglobals
+var (
+	orgHeadingRE  = regexp.MustCompile(`^(\*+)\s+(.*)$`)
+	orgHeadTagsRE = regexp.MustCompile(`\s+(:[\w@#%]+)+:\s*$`)
+	orgKeywordRE  = regexp.MustCompile(`^#\+(\w+):\s*(.*)$`)
+	orgListRE     = regexp.MustCompile(`^(\s*)([-+]|\s\*|\d+[.)])\s+(.*)$`)
+	orgLinkRE     = regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
+	orgRuleRE     = regexp.MustCompile(`^-{5,}$`)
+	orgTableSepRE = regexp.MustCompile(`^\|[-+|]+\|?$`)
+)
+
+//
+// orgConverter convert the Emacs Org markup into HTML.
+//
+// It support the subset of Org syntax: keywords ("#+TITLE:",
+// "#+AUTHOR:", "#+DATE:", and others) as metadata, headings, ordered and
+// unordered lists, links, text emphasis, source code and example blocks,
+// quote blocks, fixed-width lines, horizontal rules, and tables.
+//
+type orgConverter struct{}
+
+func newOrgConverter() *orgConverter {
+	return &orgConverter{}
+}
+
+func (org *orgConverter) Extensions() []string {
+	return []string{extOrg}
+}
+
+func (org *orgConverter) Convert(in []byte, out io.Writer) (
+	metadata map[string]interface{}, err error,
+) {
+	content := strings.ReplaceAll(string(in), "\r\n", "\n")
+
+	orgp := &orgParser{
+		lines:    strings.Split(content, "\n"),
+		metadata: make(map[string]interface{}),
+		ids:      make(map[string]int),
+	}
+
+	orgp.parse()
+
+	_, err = out.Write(orgp.out.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return orgp.metadata, nil
+}
+
+//
+// orgParser contains the state for parsing single Org document.
+//
+type orgParser struct {
+	lines    []string
+	x        int
+	out      bytes.Buffer
+	metadata map[string]interface{}
+	ids      map[string]int
+}
+
+func (orgp *orgParser) parse() {
+	for orgp.x < len(orgp.lines) {
+		line := orgp.lines[orgp.x]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case len(trimmed) == 0:
+			orgp.x++
+		case strings.HasPrefix(trimmed, "#+"):
+			orgp.parseKeywordOrBlock(trimmed)
+		case trimmed == "#" || strings.HasPrefix(trimmed, "# "):
+			// Comment line.
+			orgp.x++
+		case orgHeadingRE.MatchString(line):
+			orgp.parseHeading(line)
+		case strings.HasPrefix(trimmed, "|"):
+			orgp.parseTable()
+		case orgListRE.MatchString(line):
+			orgp.parseList(orgIndent(line))
+		case orgRuleRE.MatchString(trimmed):
+			orgp.out.WriteString("<hr>\n")
+			orgp.x++
+		case trimmed == ":" || strings.HasPrefix(trimmed, ": "):
+			orgp.parseFixedWidth()
+		default:
+			orgp.parseParagraph()
+		}
+	}
+}
+
+//
+// isBlockStart return true if the line start new element other than
+// paragraph.
+//
+func (orgp *orgParser) isBlockStart(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) == 0 ||
+		strings.HasPrefix(trimmed, "#+") ||
+		strings.HasPrefix(trimmed, "|") ||
+		trimmed == ":" || strings.HasPrefix(trimmed, ": ") ||
+		orgHeadingRE.MatchString(line) ||
+		orgListRE.MatchString(line) ||
+		orgRuleRE.MatchString(trimmed)
+}
+
+func (orgp *orgParser) parseKeywordOrBlock(trimmed string) {
+	upper := strings.ToUpper(trimmed)
+	if strings.HasPrefix(upper, "#+BEGIN_") {
+		orgp.parseBlock(trimmed)
+		return
+	}
+
+	orgp.x++
+
+	m := orgKeywordRE.FindStringSubmatch(trimmed)
+	if m == nil {
+		return
+	}
+	orgp.metadata[strings.ToLower(m[1])] = strings.TrimSpace(m[2])
+}
+
+//
+// parseBlock parse the lines between "#+BEGIN_NAME" and "#+END_NAME".
+//
+func (orgp *orgParser) parseBlock(begin string) {
+	fields := strings.Fields(begin)
+	name := strings.TrimPrefix(strings.ToUpper(fields[0]), "#+BEGIN_")
+	end := "#+END_" + name
+
+	var lines []string
+
+	orgp.x++
+	for ; orgp.x < len(orgp.lines); orgp.x++ {
+		line := orgp.lines[orgp.x]
+		if strings.ToUpper(strings.TrimSpace(line)) == end {
+			orgp.x++
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	content := strings.Join(lines, "\n")
+
+	switch name {
+	case "SRC":
+		if len(fields) > 1 {
+			fmt.Fprintf(&orgp.out, "<pre><code class=\"language-%s\">%s</code></pre>\n",
+				html.EscapeString(fields[1]), html.EscapeString(content))
+		} else {
+			fmt.Fprintf(&orgp.out, "<pre><code>%s</code></pre>\n",
+				html.EscapeString(content))
+		}
+	case "QUOTE":
+		orgp.out.WriteString("<blockquote>\n")
+		for _, para := range orgSplitParagraphs(lines) {
+			fmt.Fprintf(&orgp.out, "<p>%s</p>\n", orgInline(para))
+		}
+		orgp.out.WriteString("</blockquote>\n")
+	default:
+		fmt.Fprintf(&orgp.out, "<pre class=\"%s\">%s</pre>\n",
+			html.EscapeString(strings.ToLower(name)),
+			html.EscapeString(content))
+	}
+}
+
+func (orgp *orgParser) parseFixedWidth() {
+	var lines []string
+
+	for ; orgp.x < len(orgp.lines); orgp.x++ {
+		trimmed := strings.TrimSpace(orgp.lines[orgp.x])
+		if trimmed != ":" && !strings.HasPrefix(trimmed, ": ") {
+			break
+		}
+		lines = append(lines, strings.TrimPrefix(trimmed[1:], " "))
+	}
+
+	fmt.Fprintf(&orgp.out, "<pre>%s</pre>\n",
+		html.EscapeString(strings.Join(lines, "\n")))
+}
+
+//
+// parseHeading convert the Org heading into HTML heading.
+// Since the document title is set by "#+TITLE", the first level heading
+// is converted to "h2", the second level to "h3", and so on.
+//
+func (orgp *orgParser) parseHeading(line string) {
+	m := orgHeadingRE.FindStringSubmatch(line)
+	orgp.x++
+
+	level := len(m[1]) + 1
+	if level > 6 {
+		level = 6
+	}
+
+	text := orgHeadTagsRE.ReplaceAllString(m[2], "")
+	for _, kw := range []string{"TODO ", "DONE "} {
+		text = strings.TrimPrefix(text, kw)
+	}
+	text = strings.TrimSpace(text)
+
+	id := headingID(text, orgp.ids)
+
+	fmt.Fprintf(&orgp.out, "<h%d id=\"%s\">%s</h%d>\n", level, id,
+		orgInline(text), level)
+}
+
+//
+// parseList parse the list items with the same indentation as "indent",
+// including their nested list.
+//
+func (orgp *orgParser) parseList(indent int) {
+	m := orgListRE.FindStringSubmatch(orgp.lines[orgp.x])
+
+	tag := "ul"
+	if unicode.IsDigit(rune(m[2][0])) {
+		tag = "ol"
+	}
+
+	fmt.Fprintf(&orgp.out, "<%s>\n", tag)
+
+	isItemOpen := false
+
+	for orgp.x < len(orgp.lines) {
+		line := orgp.lines[orgp.x]
+
+		if len(strings.TrimSpace(line)) == 0 {
+			if !orgp.isListContinue(indent) {
+				break
+			}
+			orgp.x++
+			continue
+		}
+
+		m = orgListRE.FindStringSubmatch(line)
+		if m == nil {
+			break
+		}
+
+		itemIndent := orgIndent(line)
+		if itemIndent < indent {
+			break
+		}
+		if itemIndent > indent {
+			orgp.parseList(itemIndent)
+			continue
+		}
+
+		if isItemOpen {
+			orgp.out.WriteString("</li>\n")
+		}
+
+		text := m[3]
+		orgp.x++
+
+		// Collect the continuation lines of item.
+		for orgp.x < len(orgp.lines) {
+			next := orgp.lines[orgp.x]
+			if len(strings.TrimSpace(next)) == 0 ||
+				orgListRE.MatchString(next) ||
+				orgIndent(next) <= itemIndent {
+				break
+			}
+			text += "\n" + strings.TrimSpace(next)
+			orgp.x++
+		}
+
+		fmt.Fprintf(&orgp.out, "<li>%s", orgInline(text))
+		isItemOpen = true
+	}
+
+	if isItemOpen {
+		orgp.out.WriteString("</li>\n")
+	}
+	fmt.Fprintf(&orgp.out, "</%s>\n", tag)
+}
+
+//
+// isListContinue return true if the next non-empty line is a list item
+// with indentation equal or greater than "indent".
+//
+func (orgp *orgParser) isListContinue(indent int) bool {
+	for x := orgp.x; x < len(orgp.lines); x++ {
+		line := orgp.lines[x]
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		return orgListRE.MatchString(line) && orgIndent(line) >= indent
+	}
+	return false
+}
+
+func (orgp *orgParser) parseParagraph() {
+	var lines []string
+
+	for orgp.x < len(orgp.lines) {
+		line := orgp.lines[orgp.x]
+		if len(lines) > 0 && orgp.isBlockStart(line) {
+			break
+		}
+		lines = append(lines, strings.TrimSpace(line))
+		orgp.x++
+	}
+
+	fmt.Fprintf(&orgp.out, "<p>%s</p>\n", orgInline(strings.Join(lines, "\n")))
+}
+
+//
+// parseTable convert the Org table into HTML table.
+// The rows before the first separator line are rendered as table header.
+//
+func (orgp *orgParser) parseTable() {
+	var (
+		rows      [][]string
+		headerLen int
+	)
+
+	for ; orgp.x < len(orgp.lines); orgp.x++ {
+		trimmed := strings.TrimSpace(orgp.lines[orgp.x])
+		if !strings.HasPrefix(trimmed, "|") {
+			break
+		}
+		if orgTableSepRE.MatchString(trimmed) {
+			if headerLen == 0 {
+				headerLen = len(rows)
+			}
+			continue
+		}
+
+		trimmed = strings.TrimSuffix(strings.TrimPrefix(trimmed, "|"), "|")
+		cells := strings.Split(trimmed, "|")
+		for x := range cells {
+			cells[x] = strings.TrimSpace(cells[x])
+		}
+		rows = append(rows, cells)
+	}
+
+	orgp.out.WriteString("<table>\n")
+	if headerLen > 0 && headerLen < len(rows) {
+		orgp.out.WriteString("<thead>\n")
+		for _, row := range rows[:headerLen] {
+			orgp.writeTableRow("th", row)
+		}
+		orgp.out.WriteString("</thead>\n")
+		rows = rows[headerLen:]
+	}
+	orgp.out.WriteString("<tbody>\n")
+	for _, row := range rows {
+		orgp.writeTableRow("td", row)
+	}
+	orgp.out.WriteString("</tbody>\n</table>\n")
+}
+
+func (orgp *orgParser) writeTableRow(tag string, cells []string) {
+	orgp.out.WriteString("<tr>")
+	for _, cell := range cells {
+		fmt.Fprintf(&orgp.out, "<%s>%s</%s>", tag, orgInline(cell), tag)
+	}
+	orgp.out.WriteString("</tr>\n")
+}
+
+//
+// orgIndent return the number of leading white spaces in line.
+//
+func orgIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+//
+// orgSplitParagraphs split the lines into paragraphs separated by empty
+// line.
+//
+func orgSplitParagraphs(lines []string) (paras []string) {
+	var cur []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			if len(cur) > 0 {
+				paras = append(paras, strings.Join(cur, "\n"))
+				cur = nil
+			}
+			continue
+		}
+		cur = append(cur, line)
+	}
+	if len(cur) > 0 {
+		paras = append(paras, strings.Join(cur, "\n"))
+	}
+	return paras
+}
+
+//
+// orgInline convert the Org inline markup, links and text emphasis, into
+// HTML.
+//
+func orgInline(text string) string {
+	var sb strings.Builder
+
+	last := 0
+	for _, loc := range orgLinkRE.FindAllStringSubmatchIndex(text, -1) {
+		sb.WriteString(orgEmphasis(text[last:loc[0]]))
+
+		link := text[loc[2]:loc[3]]
+		desc := ""
+		if loc[4] >= 0 {
+			desc = text[loc[4]:loc[5]]
+		}
+		sb.WriteString(orgLink(link, desc))
+
+		last = loc[1]
+	}
+	sb.WriteString(orgEmphasis(text[last:]))
+
+	return sb.String()
+}
+
+//
+// orgLink convert the Org link into HTML anchor, or image if the link
+// point to an image without description.
+// The link with dangerous URL is rendered with empty "href", like goldmark
+// does on markdown.
+//
+func orgLink(link, desc string) string {
+	link = strings.TrimPrefix(link, "file:")
+	if orgIsDangerousURL(link) {
+		if len(desc) == 0 {
+			desc = link
+		}
+		return `<a href="">` + orgEmphasis(desc) + `</a>`
+	}
+	if !strings.Contains(link, "://") && strings.HasSuffix(link, extOrg) {
+		link = strings.TrimSuffix(link, extOrg) + ".html"
+	}
+	href := html.EscapeString(link)
+
+	if len(desc) == 0 {
+		switch strings.ToLower(link[strings.LastIndexByte(link, '.')+1:]) {
+		case "gif", "jpeg", "jpg", "png", "svg", "webp":
+			return `<img src="` + href + `" alt="` + href + `">`
+		}
+		return `<a href="` + href + `">` + href + `</a>`
+	}
+
+	return `<a href="` + href + `">` + orgEmphasis(desc) + `</a>`
+}
+
+//
+// orgIsDangerousURL return true if the link use scheme that can execute
+// script on browser: "javascript:", "vbscript:", or "data:" other than
+// PNG, GIF, JPEG, and WebP images.
+// The scheme is compared case insensitively, ignoring the white spaces and
+// control characters that are ignored by browser.
+//
+func orgIsDangerousURL(link string) bool {
+	var sb strings.Builder
+
+	for _, r := range link {
+		if r == ':' {
+			sb.WriteRune(r)
+			break
+		}
+		if r <= ' ' || r == 0x7f {
+			continue
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+
+	switch sb.String() {
+	case "javascript:", "vbscript:":
+		return true
+	case "data:":
+	default:
+		return false
+	}
+
+	data := strings.ToLower(link[strings.IndexByte(link, ':')+1:])
+	for _, image := range []string{"image/png", "image/gif", "image/jpeg", "image/webp"} {
+		if strings.HasPrefix(data, image) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//
+// orgEmphasis convert the Org text emphasis into HTML, and escape the rest
+// of text.
+// The text is scanned byte by byte, since all of the markers are ASCII
+// characters, and the text between markers is escaped as a whole to keep
+// the multi-byte UTF-8 characters intact.
+//
+func orgEmphasis(text string) string {
+	var sb strings.Builder
+
+	last := 0
+	for x := 0; x < len(text); x++ {
+		c := text[x]
+
+		tag := orgEmphasisTag(c)
+		if len(tag) == 0 || !orgIsPreEmphasis(text, x) {
+			continue
+		}
+
+		end := orgEmphasisEnd(text, x)
+		if end < 0 {
+			continue
+		}
+
+		sb.WriteString(html.EscapeString(text[last:x]))
+
+		inner := text[x+1 : end]
+		if c == '=' || c == '~' {
+			inner = html.EscapeString(inner)
+		} else {
+			inner = orgEmphasis(inner)
+		}
+
+		sb.WriteString("<" + tag + ">" + inner + "</" + tag + ">")
+		x = end
+		last = end + 1
+	}
+	sb.WriteString(html.EscapeString(text[last:]))
+
+	return sb.String()
+}
+
+func orgEmphasisTag(c byte) string {
+	switch c {
+	case '*':
+		return "strong"
+	case '/':
+		return "em"
+	case '_':
+		return "u"
+	case '=', '~':
+		return "code"
+	case '+':
+		return "del"
+	}
+	return ""
+}
+
+//
+// orgIsPreEmphasis return true if the character before text[x] allow the
+// emphasis marker to start.
+//
+func orgIsPreEmphasis(text string, x int) bool {
+	if x == 0 {
+		return true
+	}
+	return strings.IndexByte(" \t\n('\"{[-", text[x-1]) >= 0
+}
+
+//
+// orgEmphasisEnd return the index of closing marker for emphasis started at
+// text[start], or -1 if no closing marker found.
+//
+func orgEmphasisEnd(text string, start int) int {
+	marker := text[start]
+
+	if start+1 >= len(text) || orgIsSpace(text[start+1]) {
+		return -1
+	}
+
+	for x := start + 2; x < len(text); x++ {
+		if text[x] != marker {
+			continue
+		}
+		if orgIsSpace(text[x-1]) {
+			continue
+		}
+		if x+1 < len(text) &&
+			strings.IndexByte(" \t\n-.,;:!?'\")}[", text[x+1]) < 0 {
+			continue
+		}
+		return x
+	}
+
+	return -1
+}
+
+//
+// orgIsSpace return true if c is an ASCII white space.
+// The byte of multi-byte UTF-8 character is never a white space, although
+// its value as rune may be, for example 0x85 or 0xA0.
+//
+func orgIsSpace(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsSpace(rune(c))
+}
@@ -0,0 +1,95 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestOrgConverter_Convert(t *testing.T) {
+	cases := []struct {
+		desc        string
+		in          string
+		exp         string
+		expMetadata map[string]interface{}
+	}{{
+		desc: "With non-ASCII heading and paragraph",
+		in:   "* Überschrift\n\nnaïve 日本",
+		exp: "<h2 id=\"überschrift\">Überschrift</h2>\n" +
+			"<p>naïve 日本</p>\n",
+	}, {
+		desc: "With emphasis around non-ASCII text",
+		in:   "Å *fett* /kursiv/ =código= +alt+ à_b",
+		exp: "<p>Å <strong>fett</strong> <em>kursiv</em> " +
+			"<code>código</code> <del>alt</del> à_b</p>\n",
+	}, {
+		desc: "With UTF-8 bytes that are white space as rune",
+		in:   "*Å* *à*",
+		exp:  "<p><strong>Å</strong> <strong>à</strong></p>\n",
+	}, {
+		desc: "With HTML special characters",
+		in:   "a *b & c* <d> =<e>=",
+		exp: "<p>a <strong>b &amp; c</strong> &lt;d&gt; " +
+			"<code>&lt;e&gt;</code></p>\n",
+	}, {
+		desc: "With links",
+		in:   "See [[https://example.com][Beispiel *ü*]] and [[other.org]].",
+		exp: "<p>See <a href=\"https://example.com\">Beispiel " +
+			"<strong>ü</strong></a> and " +
+			"<a href=\"other.html\">other.html</a>.</p>\n",
+	}, {
+		desc: "With dangerous links",
+		in: "[[javascript:alert(1)][x]] [[ JavaScript:alert(1)]] " +
+			"[[vbscript:msgbox(1)][y]] [[data:text/html,<b>][z]]",
+		exp: "<p><a href=\"\">x</a> <a href=\"\"> JavaScript:alert(1)</a> " +
+			"<a href=\"\">y</a> <a href=\"\">z</a></p>\n",
+	}, {
+		desc: "With data image link",
+		in:   "[[data:image/png;base64,AAAA][img]]",
+		exp: "<p><a href=\"data:image/png;base64,AAAA\">img</a>" +
+			"</p>\n",
+	}, {
+		desc: "With image link",
+		in:   "[[file:bild.png]]",
+		exp:  "<p><img src=\"bild.png\" alt=\"bild.png\"></p>\n",
+	}, {
+		desc: "With table",
+		in:   "| Name | Größe |\n|------+-------|\n| Äpfel | 3 < 4 |",
+		exp: "<table>\n<thead>\n" +
+			"<tr><th>Name</th><th>Größe</th></tr>\n" +
+			"</thead>\n<tbody>\n" +
+			"<tr><td>Äpfel</td><td>3 &lt; 4</td></tr>\n" +
+			"</tbody>\n</table>\n",
+	}, {
+		desc: "With keywords",
+		in:   "#+TITLE: Grüße\n#+AUTHOR: Jörg\n\nText.",
+		exp:  "<p>Text.</p>\n",
+		expMetadata: map[string]interface{}{
+			"title":  "Grüße",
+			"author": "Jörg",
+		},
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		var out bytes.Buffer
+
+		metadata, err := newOrgConverter().Convert([]byte(c.in), &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.Assert(t, "HTML", c.exp, out.String(), true)
+
+		if c.expMetadata == nil {
+			c.expMetadata = map[string]interface{}{}
+		}
+		test.Assert(t, "metadata", c.expMetadata, metadata, true)
+	}
+}
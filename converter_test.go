@@ -0,0 +1,116 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"html"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+//
+// textConverter convert plain text into HTML pre-formatted text.
+//
+type textConverter struct{}
+
+func (conv *textConverter) Extensions() []string {
+	return []string{".TXT", ""}
+}
+
+func (conv *textConverter) Convert(in []byte, out io.Writer) (
+	metadata map[string]interface{}, err error,
+) {
+	_, err = io.WriteString(out, "<pre>"+html.EscapeString(string(in))+"</pre>")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"title": "Plain text"}, nil
+}
+
+func TestRegisterConverter(t *testing.T) {
+	convertersLock.Lock()
+	orgConverters := converters
+	converters = newDefaultConverters()
+	convertersLock.Unlock()
+
+	defer func() {
+		convertersLock.Lock()
+		converters = orgConverters
+		convertersLock.Unlock()
+	}()
+
+	RegisterConverter(nil)
+	RegisterConverter(&textConverter{})
+
+	cases := []struct {
+		desc string
+		ext  string
+		exp  bool
+	}{{
+		desc: "With registered extension",
+		ext:  ".txt",
+		exp:  true,
+	}, {
+		desc: "With registered extension in upper case",
+		ext:  ".Txt",
+		exp:  true,
+	}, {
+		desc: "With default extension",
+		ext:  ".adoc",
+		exp:  true,
+	}, {
+		desc: "With empty extension",
+		ext:  "",
+	}, {
+		desc: "With unknown extension",
+		ext:  ".html",
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		test.Assert(t, "isExtensionMarkup", c.exp, isExtensionMarkup(c.ext), true)
+	}
+
+	t.Log("With registered extension in markup patterns")
+
+	got := strings.Join(markupPatterns(), " ")
+	test.Assert(t, "markupPatterns", true,
+		strings.Contains(got, `.*\.txt$`), true)
+
+	t.Log("With markup file converted by registered converter")
+
+	dir, err := ioutil.TempDir("", "ciigo-converter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("a < b"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ConvertWithOptions(&ConvertOptions{Root: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "notes.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, "body", true,
+		strings.Contains(string(b), "<pre>a &lt; b</pre>"), true)
+	test.Assert(t, "title", true,
+		strings.Contains(string(b), "<title>Plain text</title>"), true)
+}
@@ -4,15 +4,27 @@
 
 package ciigo
 
-import "html/template"
-
-var _embeddedCSS *template.CSS
-
+import (
+	"html/template"
+	"sync"
+)
+
+//nolint: gochecknoglobals
+var (
+	_embeddedCSS     *template.CSS
+	_embeddedCSSOnce sync.Once
+)
+
+//
+// embeddedCSS return the embedded stylesheet.
+// It is safe to be called concurrently.
+//
 func embeddedCSS() *template.CSS {
-	if _embeddedCSS != nil {
-		return _embeddedCSS
-	}
+	_embeddedCSSOnce.Do(initEmbeddedCSS)
+	return _embeddedCSS
+}
 
+func initEmbeddedCSS() {
 	css := template.CSS(`
 body {
 	margin: 0;
@@ -214,5 +226,4 @@ dd {
 }
 `)
 	_embeddedCSS = &css
-	return _embeddedCSS
 }
@@ -0,0 +1,327 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	fileFeedAtom = "atom.xml"
+	fileFeedRSS  = "feed.xml"
+	feedAtomNS   = "http://www.w3.org/2005/Atom"
+	feedDCNS     = "http://purl.org/dc/elements/1.1/"
+
+	// summaryLength define the maximum number of characters in the
+	// summary of page.
+	summaryLength = 280
+)
+
+//nolint: gochecknoglobals
+var summaryParagraphRE = regexp.MustCompile(`(?is)<p(?:\s[^>]*)?>(.*?)</p>`)
+
+//
+// feedItem contains the page to be published in the feed.
+//
+type feedItem struct {
+	title   string
+	link    string
+	author  string
+	summary string
+	date    time.Time
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	XMLNSDC string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description,omitempty"`
+
+	// Creator contains the author name.
+	// The RSS element "author" is not used since it require an email
+	// address.
+	Creator string `xml:"dc:creator,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"feed"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Title   string       `xml:"title"`
+	Link    atomLink     `xml:"link"`
+	ID      string       `xml:"id"`
+	Updated string       `xml:"updated"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	Link    atomLink    `xml:"link"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Summary string      `xml:"summary,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+//
+// writeFeeds write the RSS 2.0 feed "feed.xml" and Atom feed "atom.xml" for
+// each of directory in "feeds".
+// Each feed directory is relative to the "root" directory, and its feed
+// files are written into the same directory inside the output directory
+// "dir".
+//
+// The feed contains all pages inside the feed directory, recursively, that
+// have a valid "date" metadata, sorted from the newest.
+// The link of each page is the "baseURL" joined with the page path.
+//
+func writeFeeds(dir, root, baseURL string, feeds []string, fileMarkups []*fileMarkup) (
+	err error,
+) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	for _, feedDir := range feeds {
+		feedDir = path.Clean(filepath.ToSlash(feedDir))
+
+		title, items, err := feedItems(root, baseURL, feedDir, fileMarkups)
+		if err != nil {
+			return fmt.Errorf("writeFeeds: %w", err)
+		}
+
+		link := baseURL + "/"
+		if feedDir != "." {
+			link += feedDir + "/"
+		}
+
+		outDir := filepath.Join(dir, filepath.FromSlash(feedDir))
+
+		err = os.MkdirAll(outDir, 0755)
+		if err != nil {
+			return fmt.Errorf("writeFeeds: %w", err)
+		}
+
+		err = writeFeedRSS(filepath.Join(outDir, fileFeedRSS), title,
+			link, items)
+		if err != nil {
+			return fmt.Errorf("writeFeeds: %w", err)
+		}
+
+		err = writeFeedAtom(filepath.Join(outDir, fileFeedAtom), title,
+			link, items)
+		if err != nil {
+			return fmt.Errorf("writeFeeds: %w", err)
+		}
+	}
+
+	return nil
+}
+
+//
+// feedItems return the feed title and the dated pages inside the feed
+// directory.
+// The feed title is the title of the directory index page, or the
+// directory name if the index page does not exist.
+//
+func feedItems(root, baseURL, feedDir string, fileMarkups []*fileMarkup) (
+	title string, items []*feedItem, err error,
+) {
+	title = path.Base(feedDir)
+	indexPath := path.Join(feedDir, "index")
+
+	for _, fmarkup := range fileMarkups {
+		rel, err := filepath.Rel(root, fmarkup.basePath)
+		if err != nil {
+			return "", nil, err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == indexPath {
+			title = fmarkup.page.navTitle(title)
+			continue
+		}
+		if feedDir != "." && !strings.HasPrefix(rel, feedDir+"/") {
+			continue
+		}
+
+		date, ok := fmarkup.page.time()
+		if !ok {
+			continue
+		}
+
+		link, err := pageURL(root, fmarkup)
+		if err != nil {
+			return "", nil, err
+		}
+
+		items = append(items, &feedItem{
+			title:   fmarkup.page.navTitle(path.Base(rel)),
+			link:    baseURL + link,
+			author:  fmarkup.page.Author,
+			summary: fmarkup.page.Summary,
+			date:    date,
+		})
+	}
+
+	sort.SliceStable(items, func(x, y int) bool {
+		if !items[x].date.Equal(items[y].date) {
+			return items[x].date.After(items[y].date)
+		}
+		return items[x].title < items[y].title
+	})
+
+	return title, items, nil
+}
+
+func writeFeedRSS(file, title, link string, items []*feedItem) error {
+	rss := &rssFeed{
+		Version: "2.0",
+		XMLNSDC: feedDCNS,
+		Channel: rssChannel{
+			Title:       title,
+			Link:        link,
+			Description: title,
+			Items:       make([]*rssItem, 0, len(items)),
+		},
+	}
+	if len(items) > 0 {
+		rss.Channel.LastBuildDate = items[0].date.Format(time.RFC1123Z)
+	}
+
+	for _, item := range items {
+		rss.Channel.Items = append(rss.Channel.Items, &rssItem{
+			Title:       item.title,
+			Link:        item.link,
+			GUID:        item.link,
+			PubDate:     item.date.Format(time.RFC1123Z),
+			Description: item.summary,
+			Creator:     item.author,
+		})
+	}
+
+	return writeXML(file, rss)
+}
+
+func writeFeedAtom(file, title, link string, items []*feedItem) error {
+	atom := &atomFeed{
+		XMLNS:   feedAtomNS,
+		Title:   title,
+		Link:    atomLink{Href: link},
+		ID:      link,
+		Entries: make([]*atomEntry, 0, len(items)),
+	}
+	// The Atom feed require the "updated" element.
+	// Without any item, use the zero Unix time instead of current time,
+	// so the feed is not changed on each build.
+	updated := time.Unix(0, 0).UTC()
+	if len(items) > 0 {
+		updated = items[0].date
+	}
+	atom.Updated = updated.Format(time.RFC3339)
+
+	for _, item := range items {
+		entry := &atomEntry{
+			Title:   item.title,
+			Link:    atomLink{Href: item.link},
+			ID:      item.link,
+			Updated: item.date.Format(time.RFC3339),
+			Summary: item.summary,
+		}
+		if len(item.author) > 0 {
+			entry.Author = &atomAuthor{Name: item.author}
+		}
+		atom.Entries = append(atom.Entries, entry)
+	}
+
+	return writeXML(file, atom)
+}
+
+//
+// writeXML encode the value "v" as indented XML into file.
+//
+func writeXML(file string, v interface{}) (err error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "\t")
+
+	err = enc.Encode(v)
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+
+	return ioutil.WriteFile(file, buf.Bytes(), 0644)
+}
+
+//
+// htmlSummary return the text of the first paragraph in HTML body, without
+// HTML tags, truncated to summaryLength characters.
+//
+func htmlSummary(body string) string {
+	m := summaryParagraphRE.FindStringSubmatch(body)
+	if m == nil {
+		return ""
+	}
+
+	text := tocTagRE.ReplaceAllString(m[1], "")
+	text = html.UnescapeString(text)
+	text = strings.Join(strings.Fields(text), " ")
+
+	return truncateText(text, summaryLength)
+}
+
+//
+// truncateText truncate the text to the last word before "length"
+// characters, with "..." appended to it.
+//
+func truncateText(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	text = string(runes[:length])
+	x := strings.LastIndexByte(text, ' ')
+	if x > 0 {
+		text = text[:x]
+	}
+
+	return text + "..."
+}
@@ -0,0 +1,123 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestWriteFeedAtom(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ciigo-feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, fileFeedAtom)
+	date := time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		desc  string
+		items []*feedItem
+		exp   string
+	}{{
+		desc: "Without items",
+		exp:  "<updated>1970-01-01T00:00:00Z</updated>",
+	}, {
+		desc: "With items",
+		items: []*feedItem{{
+			title: "Post",
+			link:  "https://example.com/post.html",
+			date:  date,
+		}},
+		exp: "<updated>2020-07-01T10:00:00Z</updated>",
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		err = writeFeedAtom(file, "Blog", "https://example.com/", c.items)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.Assert(t, "updated", true,
+			strings.Contains(string(got), "\n\t"+c.exp+"\n"), true)
+	}
+}
+
+func TestWriteFeedRSS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ciigo-feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, fileFeedRSS)
+	items := []*feedItem{{
+		title:  "Post",
+		link:   "https://example.com/post.html",
+		author: "Jörg",
+		date:   time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC),
+	}}
+
+	err = writeFeedRSS(file, "Blog", "https://example.com/", items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, "dc:creator", true,
+		strings.Contains(string(got), "<dc:creator>Jörg</dc:creator>"), true)
+	test.Assert(t, "author", false,
+		strings.Contains(string(got), "<author>"), true)
+}
+
+func TestConvertOptions_Feeds(t *testing.T) {
+	cases := []struct {
+		desc  string
+		opts  *ConvertOptions
+		isErr bool
+	}{{
+		desc: "Without Feeds and BaseURL",
+		opts: &ConvertOptions{},
+	}, {
+		desc: "With Feeds and BaseURL",
+		opts: &ConvertOptions{
+			Feeds:   []string{"blog"},
+			BaseURL: "https://example.com",
+		},
+	}, {
+		desc: "With Feeds without BaseURL",
+		opts: &ConvertOptions{
+			Feeds: []string{"blog"},
+		},
+		isErr: true,
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		err := c.opts.init()
+
+		test.Assert(t, "is error", c.isErr, err != nil, true)
+	}
+}
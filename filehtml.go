@@ -19,51 +19,42 @@ type fileHTML struct {
 	EmbeddedCSS *template.CSS
 	Styles      []string
 	Body        template.HTML
-	Metadata    map[string]string
+	Metadata    Metadata
 
-	path    string
-	rawBody strings.Builder
-}
+	// TOC contains the headings in the Body, in the order of their
+	// appearance.
+	TOC []*TOCEntry
 
-//
-// reset all fields to its empty values.
-//
-func (fhtml *fileHTML) reset() {
-	fhtml.Title = ""
-	fhtml.Author = ""
-	fhtml.Date = ""
-	fhtml.EmbeddedCSS = nil
-	fhtml.Styles = fhtml.Styles[:0]
-	fhtml.Body = template.HTML("")
+	// Nav contains the root of site navigation tree.
+	Nav *NavNode
 
-	fhtml.path = ""
-	fhtml.rawBody.Reset()
+	// URL contains the absolute URL path of the page, for example
+	// "/sub/index.html".
+	URL string
+
+	// SearchURL contains the URL path of the search page.
+	SearchURL string
+
+	path    string
+	rawBody strings.Builder
 }
 
 //
 // unpackMarkup convert the markup metadata to its HTML representation and
-// rawBody to template.HTML.
+// rawBody to template.HTML, and extract its table of contents.
 //
 func (fhtml *fileHTML) unpackMarkup(fa *fileMarkup) {
-	fhtml.Metadata = make(map[string]string)
+	fhtml.Metadata = fa.metadata
+	fhtml.Author = fa.metadata.String(metadataAuthor)
+	fhtml.Date = fa.metadata.String(metadataDate)
+	fhtml.Title = fa.metadata.String(metadataTitle)
+	fhtml.Styles = append(fhtml.Styles, fa.metadata.List(metadataStylesheet)...)
 
-	for k, v := range fa.metadata {
-		switch k {
-		case metadataAuthor:
-			fhtml.Author = v.(string)
-		case metadataDate:
-			fhtml.Date = v.(string)
-		case metadataTitle:
-			fhtml.Title = v.(string)
-		case metadataStylesheet:
-			fhtml.Styles = append(fhtml.Styles, v.(string))
-		default:
-			fhtml.Metadata[k] = v.(string)
-		}
-	}
 	if len(fhtml.Styles) == 0 {
 		fhtml.EmbeddedCSS = embeddedCSS()
 	}
 
-	fhtml.Body = template.HTML(fhtml.rawBody.String()) // nolint:gosec
+	var body string
+	fhtml.TOC, body = parseTOC(fhtml.rawBody.String())
+	fhtml.Body = template.HTML(body) // nolint:gosec
 }
@@ -6,18 +6,19 @@ package ciigo
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path"
 	"strings"
 )
 
 type fileMarkup struct {
-	kind     byte                   // kind define the type of markup file.
-	path     string                 // path contains full path to markup file.
-	info     os.FileInfo            // info contains FileInfo of markup file.
-	basePath string                 // basePath contains full path to file without markup extension.
-	metadata map[string]interface{} // metadata contains markup metadata.
+	ext      string      // ext contains the lower case extension of markup file.
+	path     string      // path contains full path to markup file.
+	info     os.FileInfo // info contains FileInfo of markup file.
+	basePath string      // basePath contains full path to file without markup extension.
+	htmlPath string      // htmlPath contains full path to the generated HTML file.
+	metadata Metadata    // metadata contains markup metadata.
+	page     pageInfo    // page contains the metadata used by navigation, sitemap, and others.
 }
 
 func newFileMarkup(filePath string, fi os.FileInfo) (fmarkup *fileMarkup, err error) {
@@ -27,46 +28,23 @@ func newFileMarkup(filePath string, fi os.FileInfo) (fmarkup *fileMarkup, err er
 	if fi == nil {
 		fi, err = os.Stat(filePath)
 		if err != nil {
-			return nil, fmt.Errorf("newFileMarkup: " + err.Error())
+			return nil, fmt.Errorf("newFileMarkup: %w", err)
 		}
 	}
 
 	ext := strings.ToLower(path.Ext(filePath))
 
 	fmarkup = &fileMarkup{
-		kind: markupKind(ext),
+		ext:  ext,
 		path: filePath,
 		info: fi,
 	}
-	if fmarkup.kind == markupKindUnknown {
-		return nil, fmt.Errorf("newFileMarkup: unknown markup file " + filePath)
+	if !isExtensionMarkup(ext) {
+		return nil, fmt.Errorf("newFileMarkup: unknown markup file %s", filePath)
 	}
 
 	fmarkup.basePath = strings.TrimSuffix(filePath, ext)
+	fmarkup.htmlPath = fmarkup.basePath + ".html"
 
 	return fmarkup, nil
 }
-
-//
-// isHTMLLatest will return true if generated HTML is exist and its
-// modification time is equal or greater than their markup file; otherwise
-// it will return false.
-//
-func (fa *fileMarkup) isHTMLLatest(htmlPath string) bool {
-	htmlInfo, err := os.Stat(htmlPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return false
-		}
-		log.Printf("ciigo: os.Stat(%q): %s\n", htmlPath, err)
-		return false
-	}
-	if htmlInfo == nil {
-		return false
-	}
-
-	infoTime := fa.info.ModTime()
-	htmlTime := htmlInfo.ModTime()
-
-	return htmlTime.Equal(infoTime) || htmlTime.After(infoTime)
-}
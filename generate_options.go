@@ -0,0 +1,38 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+//
+// GenerateOptions define the options to use on GenerateWithOptions
+// function.
+//
+type GenerateOptions struct {
+	ConvertOptions
+
+	// GenPackageName define the package name for the generated Go file.
+	// This field is optional, default to "main".
+	GenPackageName string
+
+	// GenGoFileName define the path to the generated Go file.
+	// This field is optional, default to "ciigo_static.go" in current
+	// directory.
+	GenGoFileName string
+}
+
+func (opts *GenerateOptions) init() (err error) {
+	err = opts.ConvertOptions.init()
+	if err != nil {
+		return err
+	}
+
+	if len(opts.GenPackageName) == 0 {
+		opts.GenPackageName = defGenPackageName
+	}
+	if len(opts.GenGoFileName) == 0 {
+		opts.GenGoFileName = defGenGoFileName
+	}
+
+	return nil
+}
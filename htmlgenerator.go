@@ -6,14 +6,17 @@ package ciigo
 
 import (
 	"bytes"
-	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/bytesparadise/libasciidoc"
 	"github.com/yuin/goldmark"
 	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/parser"
@@ -24,114 +27,657 @@ import (
 //
 type htmlGenerator struct {
 	path       string
-	mdg        goldmark.Markdown
+	root       string
+	outDir     string
 	tmpl       *template.Template
 	tmplSearch *template.Template
+	cache      *buildCache
+	index      *searchIndex
+	workers    int
+
+	// staticSearch if its true, the files for searching the pages
+	// without ciigo server are generated.
+	staticSearch bool
+
+	// drafts if its true, the draft and future pages are published.
+	drafts bool
+
+	// searchURL contains the URL path of search page, passed to HTML
+	// template as field "SearchURL".
+	searchURL string
+
+	// searchTemplate contains the path to the search template file.
+	searchTemplate string
+
+	// templateDir contains the path to the directory of layout and
+	// partial templates.
+	templateDir string
+
+	// layouts contains the parsed layout templates, where the key is
+	// the layout name.
+	layouts map[string]*template.Template
+
+	// nav contains the navigation tree from the last conversion.
+	nav *NavNode
+
+	// mu guard the swap of templates and navigation tree by the
+	// development server, while the search requests read them.
+	// The conversion that replace them does not need to hold the read
+	// lock, since the conversions are serialized.
+	mu sync.RWMutex
+
+	// convs contains the Converter that override the registered
+	// Converter for specific extension.
+	convs map[string]Converter
+}
+
+//
+// convertResult contains the converted HTML and the error from converting
+// single markup file.
+// The fhtml is nil if the markup file is not converted.
+//
+type convertResult struct {
+	fhtml *fileHTML
+	hash  string
+	err   error
+
+	// isDraft is true if the page is not published.
+	isDraft bool
 }
 
-func newHTMLGenerator(file, content string) (htmlg *htmlGenerator) {
-	var err error
+//
+// newHTMLGenerator create new HTML generator using the "content" as the
+// default HTML template and the "layouts" as the named templates that can be
+// selected by page using metadata "layout".
+//
+func newHTMLGenerator(opts *ConvertOptions, content string, layouts map[string]string) (
+	htmlg *htmlGenerator, err error,
+) {
+	tmplContent := templatesContent(content, layouts)
 
 	htmlg = &htmlGenerator{
-		path: file,
-		mdg: goldmark.New(
-			goldmark.WithExtensions(
-				meta.Meta,
+		path:    opts.HTMLTemplate,
+		root:    opts.Root,
+		outDir:  opts.cacheDir(),
+		index:   readSearchIndex(filepath.Join(opts.cacheDir(), fileSearchIndex)),
+		nav:     &NavNode{},
+		cache:   newBuildCache(opts.cacheDir(), opts.Root, tmplContent),
+		workers: opts.Workers,
+		convs:   make(map[string]Converter),
+
+		staticSearch:   opts.StaticSearch,
+		drafts:         opts.Drafts,
+		searchURL:      pathSearch,
+		searchTemplate: opts.SearchTemplate,
+		templateDir:    opts.TemplateDir,
+	}
+	if htmlg.staticSearch {
+		htmlg.searchURL = pathStaticSearch
+	}
+	htmlg.cache.setSearchURL(htmlg.searchURL)
+	htmlg.cache.setOptions(optionsHash(opts))
+
+	if len(opts.MarkdownExtensions) > 0 {
+		mdExtensions := make([]goldmark.Extender, 0,
+			1+len(opts.MarkdownExtensions))
+		mdExtensions = append(mdExtensions, meta.Meta)
+		mdExtensions = append(mdExtensions, opts.MarkdownExtensions...)
+
+		htmlg.convs[extMarkdown] = NewMarkdownConverter(goldmark.New(
+			goldmark.WithExtensions(mdExtensions...),
+			goldmark.WithParserOptions(
+				parser.WithAutoHeadingID(),
 			),
-		),
+		))
 	}
 
-	htmlg.tmpl = template.New("")
-	htmlg.tmpl, err = htmlg.tmpl.Parse(content)
+	err = htmlg.setTemplates(content, layouts)
 	if err != nil {
-		log.Fatal("newHTMLGenerator: ", err.Error())
+		return nil, fmt.Errorf("newHTMLGenerator: %w", err)
 	}
 
-	htmlg.tmplSearch = template.New("search")
-	htmlg.tmplSearch, err = htmlg.tmplSearch.Parse(templateSearch)
+	err = htmlg.setSearchTemplate(templateSearch)
 	if err != nil {
-		log.Fatal("newHTMLGenerator: " + err.Error())
+		return nil, fmt.Errorf("newHTMLGenerator: %w", err)
 	}
 
-	return
+	return htmlg, nil
 }
 
+//
+// optionsHash return the hash of options that affect the content of
+// generated HTML files: the Drafts, the type of MarkdownExtensions, and the
+// type of registered Converter for each markup extension.
+//
+// The options of extension or Converter, for example the goldmark options
+// on Converter created by NewMarkdownConverter, can not be compared, so
+// changing them without changing their type does not invalidate the build
+// cache.
+//
+func optionsHash(opts *ConvertOptions) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "drafts:%t\n", opts.Drafts)
+	for _, ext := range opts.MarkdownExtensions {
+		fmt.Fprintf(&sb, "markdown:%T\n", ext)
+	}
+	for _, ext := range markupExtensions() {
+		fmt.Fprintf(&sb, "converter:%s:%T\n", ext, getConverter(ext))
+	}
+
+	return contentHash([]byte(sb.String()))
+}
+
+//
+// loadHTMLTemplate read the content of HTML template from file.
+// If the file is empty it will return the embedded HTML template.
+//
+func loadHTMLTemplate(file string) (content string, err error) {
+	if len(file) == 0 {
+		return templateIndexHTML, nil
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("loadHTMLTemplate: %w", err)
+	}
+
+	return string(b), nil
+}
+
+//
+// loadSearchTemplate read the content of search template from file.
+// If the file is empty it will return the embedded search template.
+//
+func loadSearchTemplate(file string) (content string, err error) {
+	if len(file) == 0 {
+		return templateSearch, nil
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("loadSearchTemplate: %w", err)
+	}
+
+	return string(b), nil
+}
+
+//
+// setSearchTemplate parse the content of search template.
+//
+func (htmlg *htmlGenerator) setSearchTemplate(content string) (err error) {
+	tmpl, err := template.New("search").Parse(content)
+	if err != nil {
+		return fmt.Errorf("setSearchTemplate: %w", err)
+	}
+
+	htmlg.mu.Lock()
+	htmlg.tmplSearch = tmpl
+	htmlg.mu.Unlock()
+
+	return nil
+}
+
+//
+// reloadSearchTemplate read and parse the search template file.
+//
+func (htmlg *htmlGenerator) reloadSearchTemplate() (err error) {
+	content, err := loadSearchTemplate(htmlg.searchTemplate)
+	if err != nil {
+		return fmt.Errorf("reloadSearchTemplate: %w", err)
+	}
+
+	return htmlg.setSearchTemplate(content)
+}
+
+//
+// reloadTemplate read and parse the HTML template file and the layout
+// templates, and update the template hash in the build cache.
+//
 func (htmlg *htmlGenerator) reloadTemplate() (err error) {
-	htmlg.tmpl, err = template.ParseFiles(htmlg.path)
+	content, err := loadHTMLTemplate(htmlg.path)
+	if err != nil {
+		return fmt.Errorf("reloadTemplate: %w", err)
+	}
 
-	return
+	layouts, err := loadLayouts(htmlg.templateDir)
+	if err != nil {
+		return fmt.Errorf("reloadTemplate: %w", err)
+	}
+
+	err = htmlg.setTemplates(content, layouts)
+	if err != nil {
+		return fmt.Errorf("reloadTemplate: %w", err)
+	}
+
+	htmlg.cache.setTemplate(templatesContent(content, layouts))
+
+	return nil
 }
 
-func (htmlg *htmlGenerator) convertFileMarkups(fileMarkups []*fileMarkup, force bool) {
-	fhtml := &fileHTML{}
+//
+// convertFileMarkups convert each markup files into HTML files concurrently
+// using htmlg.workers goroutines.
+// If force is false, only markup files that are not latest according to the
+// build cache will be converted.
+//
+// The conversion is done in two phases.
+// First, the markup files are converted and the navigation tree is build
+// from the metadata of published pages, using the metadata in the build
+// cache for markup files that are not converted.
+// If the navigation tree has changed since the last build, all of the
+// markup files are converted, since each HTML file contains the navigation.
+// Second, the converted markup files are written into HTML files and the
+// search index, and the taxonomy pages and the static search files are
+// generated from all published pages.
+// The HTML file of unpublished page, a draft or a page with date in the
+// future, is removed.
+// It will return true if the navigation tree has changed.
+//
+// The log of each file is printed in the same order as the fileMarkups,
+// and the first error, if any, is returned after all of the workers has
+// finished.
+//
+func (htmlg *htmlGenerator) convertFileMarkups(fileMarkups []*fileMarkup, force bool) (
+	isNavChanged bool, err error,
+) {
+	results := make([]*convertResult, len(fileMarkups))
 
-	for _, fmarkup := range fileMarkups {
-		fhtml.reset()
-		fhtml.path = fmarkup.basePath + ".html"
+	htmlg.runWorkers(len(fileMarkups), func(x int) {
+		results[x] = htmlg.convert(fileMarkups[x], force)
+	})
+
+	published := htmlg.published(fileMarkups)
+
+	nav := newNavigation(htmlg.root, published)
+	isNavChanged = htmlg.cache.setNav(nav.hash())
+
+	htmlg.mu.Lock()
+	htmlg.nav = nav
+	htmlg.mu.Unlock()
 
-		fmt.Printf("ciigo: converting %q to %q ... ", fmarkup.path, fhtml.path)
+	if isNavChanged && !force {
+		htmlg.runWorkers(len(fileMarkups), func(x int) {
+			if results[x].fhtml == nil && results[x].err == nil {
+				results[x] = htmlg.convert(fileMarkups[x], true)
+			}
+		})
+	}
+
+	htmlg.runWorkers(len(fileMarkups), func(x int) {
+		result := results[x]
+		if result.err != nil {
+			return
+		}
+		if !htmlg.isPublished(fileMarkups[x]) {
+			result.isDraft = true
+			result.err = htmlg.unpublish(fileMarkups[x])
+			return
+		}
+		if result.fhtml == nil {
+			return
+		}
+		result.err = htmlg.write(fileMarkups[x], result)
+	})
+
+	for x, result := range results {
+		fmarkup := fileMarkups[x]
+
+		fmt.Printf("ciigo: converting %q to %q ... ", fmarkup.path,
+			fmarkup.htmlPath)
 
-		htmlg.convert(fmarkup, fhtml, force)
+		switch {
+		case result.err != nil:
+			fmt.Println("FAIL")
+			if err == nil {
+				err = result.err
+			}
+		case result.isDraft:
+			fmt.Println("skip (draft)")
+		case result.fhtml == nil:
+			fmt.Println("skip")
+		default:
+			fmt.Println("OK")
+			fmt.Printf("  metadata: %+v\n", fmarkup.metadata)
+		}
+	}
 
-		fmt.Println("OK")
-		fmt.Printf("  metadata: %+v\n", fmarkup.metadata)
+	htmlg.updateSearchIndex(published)
+
+	errGenerated := htmlg.writeGenerated(published)
+	if errGenerated != nil && err == nil {
+		err = errGenerated
 	}
+
+	errCache := htmlg.cache.save()
+	if errCache != nil {
+		log.Println("ciigo: " + errCache.Error())
+	}
+
+	return isNavChanged, err
 }
 
-func (htmlg *htmlGenerator) convert(fmarkup *fileMarkup, fhtml *fileHTML, force bool) {
-	if fmarkup.isHTMLLatest(fhtml.path) && !force {
-		return
+//
+// isPublished return true if the page of markup file is published, or if
+// the draft pages are included.
+//
+func (htmlg *htmlGenerator) isPublished(fmarkup *fileMarkup) bool {
+	return htmlg.drafts || fmarkup.page.isPublished(time.Now())
+}
+
+//
+// published return the markup files whose page is published.
+//
+func (htmlg *htmlGenerator) published(fileMarkups []*fileMarkup) (
+	published []*fileMarkup,
+) {
+	published = make([]*fileMarkup, 0, len(fileMarkups))
+	for _, fmarkup := range fileMarkups {
+		if htmlg.isPublished(fmarkup) {
+			published = append(published, fmarkup)
+		}
+	}
+	return published
+}
+
+//
+// unpublish remove the HTML file of markup file and its build cache, so the
+// page will be converted again when its published.
+// Any error will be returned as *ConvertError.
+//
+func (htmlg *htmlGenerator) unpublish(fmarkup *fileMarkup) (err error) {
+	htmlg.cache.remove(fmarkup.path)
+
+	err = os.Remove(fmarkup.htmlPath)
+	if err != nil && !os.IsNotExist(err) {
+		return &ConvertError{Path: fmarkup.path, Err: err}
 	}
 
+	return nil
+}
+
+//
+// runWorkers call the function "fn" with index from 0 to n-1 concurrently
+// using htmlg.workers goroutines, and wait until all of them has finished.
+//
+func (htmlg *htmlGenerator) runWorkers(n int, fn func(x int)) {
+	workers := htmlg.workers
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+
+	queue := make(chan int)
+	for x := 0; x < workers; x++ {
+		wg.Add(1)
+		go func() {
+			for x := range queue {
+				fn(x)
+			}
+			wg.Done()
+		}()
+	}
+
+	for x := 0; x < n; x++ {
+		queue <- x
+	}
+	close(queue)
+
+	wg.Wait()
+}
+
+//
+// convert the markup file into HTML body, without writing the HTML file.
+// If force is false and the HTML file is latest according to the build
+// cache, the markup file will not be converted and its page metadata is
+// loaded from the build cache.
+// Any error during conversion will be returned as *ConvertError.
+//
+func (htmlg *htmlGenerator) convert(fmarkup *fileMarkup, force bool) (
+	result *convertResult,
+) {
+	result = &convertResult{}
+
 	in, err := ioutil.ReadFile(fmarkup.path)
 	if err != nil {
-		log.Fatal("htmlGenerator.convert: " + err.Error())
+		result.err = &ConvertError{Path: fmarkup.path, Err: err}
+		return result
+	}
+
+	result.hash = contentHash(in)
+	if !force && htmlg.cache.isLatest(fmarkup.path, result.hash, fmarkup.htmlPath) &&
+		htmlg.index.has(htmlg.urlPath(fmarkup)) {
+		fmarkup.page = htmlg.cache.getPage(fmarkup.path)
+		return result
+	}
+
+	conv := htmlg.getConverter(fmarkup.ext)
+	if conv == nil {
+		err = fmt.Errorf("unknown markup extension %q", fmarkup.ext)
+		result.err = &ConvertError{Path: fmarkup.path, Err: err}
+		return result
+	}
+
+	fhtml := &fileHTML{
+		path: fmarkup.htmlPath,
+	}
+
+	raw, err := conv.Convert(in, &fhtml.rawBody)
+	if err != nil {
+		result.err = &ConvertError{Path: fmarkup.path, Err: err}
+		return result
+	}
+
+	fmarkup.metadata, err = newMetadata(raw)
+	if err != nil {
+		result.err = &ConvertError{Path: fmarkup.path, Err: err}
+		return result
+	}
+	fmarkup.page = newPageInfo(fmarkup.metadata)
+
+	if fhtml.rawBody.Len() == 0 {
+		return result
+	}
+
+	fhtml.unpackMarkup(fmarkup)
+	fmarkup.page.Summary = htmlSummary(string(fhtml.Body))
+	result.fhtml = fhtml
+
+	return result
+}
+
+//
+// getConverter return the Converter for markup extension "ext".
+//
+func (htmlg *htmlGenerator) getConverter(ext string) Converter {
+	conv, ok := htmlg.convs[ext]
+	if ok {
+		return conv
+	}
+	return getConverter(ext)
+}
+
+//
+// write the converted markup file into HTML file, and record it in the
+// build cache.
+// Any error will be returned as *ConvertError.
+//
+func (htmlg *htmlGenerator) write(fmarkup *fileMarkup, result *convertResult) (
+	err error,
+) {
+	fhtml := result.fhtml
+	fhtml.URL = htmlg.urlPath(fmarkup)
+	fhtml.Nav = htmlg.nav
+	fhtml.SearchURL = htmlg.searchURL
+
+	tmpl, err := htmlg.layout(fmarkup.metadata.String(metadataLayout))
+	if err != nil {
+		return &ConvertError{Path: fmarkup.path, Err: err}
+	}
+
+	err = htmlg.writeHTML(fhtml, tmpl)
+	if err != nil {
+		return &ConvertError{Path: fmarkup.path, Err: err}
+	}
+
+	htmlg.cache.set(fmarkup.path, result.hash, fmarkup.page)
+	htmlg.index.set(fhtml.URL, fmarkup.page, string(fhtml.Body))
+
+	return nil
+}
+
+//
+// writeGenerated write the pages and files that are not converted from
+// markup files: the navigation tree, the taxonomy pages, and the static
+// search files.
+// The generated files from the previous build that are no longer exist are
+// removed.
+//
+func (htmlg *htmlGenerator) writeGenerated(fileMarkups []*fileMarkup) (err error) {
+	nav, err := json.Marshal(htmlg.nav)
+	if err != nil {
+		return fmt.Errorf("writeGenerated: %w", err)
+	}
+
+	err = htmlg.writeGeneratedFile(fileNavigation, nav)
+	if err != nil {
+		return fmt.Errorf("writeGenerated: %w", err)
+	}
+
+	generated, err := htmlg.writeTaxonomies(fileMarkups)
+	if err != nil {
+		return err
 	}
 
-	switch fmarkup.kind {
-	case markupKindAsciidoc:
-		ctx := context.Background()
-		bufin := bytes.NewBuffer(in)
-		fmarkup.metadata, err = libasciidoc.ConvertToHTML(ctx,
-			bufin, &fhtml.rawBody)
+	if htmlg.staticSearch {
+		files, err := htmlg.writeStaticSearch()
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
+		generated = append(generated, files...)
+	}
 
-	case markupKindMarkdown:
-		ctx := parser.NewContext()
-		err := htmlg.mdg.Convert(in, &fhtml.rawBody, parser.WithContext(ctx))
-		if err != nil {
-			log.Fatal(err)
+	for _, file := range htmlg.cache.setGenerated(generated) {
+		err = os.Remove(filepath.Join(htmlg.outDir, filepath.FromSlash(file)))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("writeGenerated: %w", err)
 		}
+	}
+
+	return nil
+}
 
-		fmarkup.metadata = meta.Get(ctx)
+//
+// writeGeneratedPage render the "data" using "tmpl" as the body of HTML
+// template and write it into "file" inside the output directory, only if
+// its content has changed.
+//
+func (htmlg *htmlGenerator) writeGeneratedPage(
+	file, title string, tmpl *template.Template, data interface{},
+) (err error) {
+	var body, out bytes.Buffer
+
+	err = tmpl.Execute(&body, data)
+	if err != nil {
+		return err
 	}
-	if fhtml.rawBody.Len() == 0 {
-		fmt.Println("skip")
-		return
+
+	fhtml := &fileHTML{
+		Title:       title,
+		EmbeddedCSS: embeddedCSS(),
+		Body:        template.HTML(body.String()), //nolint: gosec
+		Nav:         htmlg.nav,
+		URL:         "/" + file,
+		SearchURL:   htmlg.searchURL,
 	}
 
-	fhtml.unpackMarkup(fmarkup)
-	htmlg.write(fhtml)
+	err = htmlg.tmpl.Execute(&out, fhtml)
+	if err != nil {
+		return err
+	}
+
+	return htmlg.writeGeneratedFile(file, out.Bytes())
+}
+
+//
+// writeGeneratedFile write the content into "file" inside the output
+// directory, only if its content has changed.
+//
+func (htmlg *htmlGenerator) writeGeneratedFile(file string, content []byte) (
+	err error,
+) {
+	sysPath := filepath.Join(htmlg.outDir, filepath.FromSlash(file))
+
+	old, err := ioutil.ReadFile(sysPath)
+	if err == nil && bytes.Equal(old, content) {
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(sysPath), 0755)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(sysPath, content, 0644)
 }
 
 //
-// write the HTML file.
+// updateSearchIndex remove the pages that does not exist anymore from the
+// search index, and save it into file.
 //
-func (htmlg *htmlGenerator) write(fhtml *fileHTML) {
+func (htmlg *htmlGenerator) updateSearchIndex(fileMarkups []*fileMarkup) {
+	paths := make(map[string]struct{}, len(fileMarkups))
+	for _, fmarkup := range fileMarkups {
+		paths[htmlg.urlPath(fmarkup)] = struct{}{}
+	}
+
+	htmlg.index.prune(paths)
+
+	err := htmlg.index.save(filepath.Join(htmlg.outDir, fileSearchIndex))
+	if err != nil {
+		log.Println("ciigo: " + err.Error())
+	}
+}
+
+//
+// urlPath return the absolute URL path of HTML file generated from markup
+// file.
+//
+func (htmlg *htmlGenerator) urlPath(fmarkup *fileMarkup) string {
+	rel, err := filepath.Rel(htmlg.root, fmarkup.basePath)
+	if err != nil {
+		return ""
+	}
+	return "/" + filepath.ToSlash(rel) + ".html"
+}
+
+//
+// writeHTML write the HTML file using the template "tmpl".
+//
+func (htmlg *htmlGenerator) writeHTML(fhtml *fileHTML, tmpl *template.Template) (
+	err error,
+) {
+	err = os.MkdirAll(filepath.Dir(fhtml.path), 0755)
+	if err != nil {
+		return fmt.Errorf("htmlGenerator.writeHTML: %w", err)
+	}
+
 	f, err := os.Create(fhtml.path)
 	if err != nil {
-		log.Fatal("htmlGenerator: write: os.Create: " + err.Error())
+		return fmt.Errorf("htmlGenerator.writeHTML: %w", err)
 	}
 
-	err = htmlg.tmpl.Execute(f, fhtml)
+	err = tmpl.Execute(f, fhtml)
 	if err != nil {
-		log.Fatal("htmlGenerator: write: Execute: " + err.Error())
+		_ = f.Close()
+		return fmt.Errorf("htmlGenerator.writeHTML: %w", err)
 	}
 
 	err = f.Close()
 	if err != nil {
-		log.Fatal("htmlGenerator: write: Close: " + err.Error())
+		return fmt.Errorf("htmlGenerator.writeHTML: %w", err)
 	}
+
+	return nil
 }
@@ -6,9 +6,16 @@
 
 package main
 
-import "github.com/shuLhan/ciigo"
+import (
+	"log"
+
+	"github.com/shuLhan/ciigo"
+)
 
 func main() {
-	ciigo.Generate("./_example", "cmd/ciigo-example/static.go",
+	err := ciigo.Generate("./_example", "cmd/ciigo-example/static.go",
 		"_example/html.tmpl")
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,248 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// extLayout is the file extension of layout template inside the
+	// template directory.
+	extLayout = ".tmpl"
+
+	// metadataLayout is the metadata key to select the layout of the
+	// page, without the file extension.
+	metadataLayout = "layout"
+
+	// prefixPartial is the prefix of layout name for partial template.
+	// The partial template can not be selected as layout, but it can be
+	// used by the HTML template and the other layouts, using its name
+	// without the prefix.
+	prefixPartial = "_"
+)
+
+//
+// listLayouts return the path of layout files, the file with extension
+// ".tmpl", inside directory "dir", sorted by name.
+// The sub directories are not scanned.
+// If the dir is empty it will return nil.
+//
+func listLayouts(dir string) (files []string, err error) {
+	if len(dir) == 0 {
+		return nil, nil
+	}
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("listLayouts: %w", err)
+	}
+
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || name[0] == '.' {
+			continue
+		}
+		if !strings.EqualFold(filepath.Ext(name), extLayout) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+
+	return files, nil
+}
+
+//
+// loadLayouts read the content of layout files inside directory "dir".
+// The returned map key is the layout name, which is the file name without
+// extension, for example "blog" for file "blog.tmpl".
+// If the dir is empty it will return nil.
+//
+func loadLayouts(dir string) (layouts map[string]string, err error) {
+	files, err := listLayouts(dir)
+	if err != nil {
+		return nil, fmt.Errorf("loadLayouts: %w", err)
+	}
+
+	layouts = make(map[string]string, len(files))
+
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("loadLayouts: %w", err)
+		}
+		layouts[layoutName(file)] = string(b)
+	}
+
+	return layouts, nil
+}
+
+//
+// layoutName return the name of layout from its file path.
+//
+func layoutName(file string) string {
+	name := filepath.Base(file)
+	return name[:len(name)-len(filepath.Ext(name))]
+}
+
+//
+// layoutNames return the name of layouts sorted in ascending order.
+//
+func layoutNames(layouts map[string]string) (names []string) {
+	names = make([]string, 0, len(layouts))
+	for name := range layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//
+// isPartial return true if the layout name is a partial template.
+//
+func isPartial(name string) bool {
+	return strings.HasPrefix(name, prefixPartial)
+}
+
+//
+// templatesContent return the content of HTML template and all layouts, in
+// the order of layout name, for computing the template hash in build cache.
+//
+func templatesContent(content string, layouts map[string]string) string {
+	var sb strings.Builder
+
+	sb.WriteString(content)
+	for _, name := range layoutNames(layouts) {
+		sb.WriteString("\n" + name + "\n")
+		sb.WriteString(layouts[name])
+	}
+
+	return sb.String()
+}
+
+//
+// setTemplates parse the content of HTML template and the layouts.
+//
+// The partial templates, the layouts whose name start with "_", are parsed
+// together first, named without the "_", so the HTML template and the
+// other layouts can include them using action "{{template "name" .}}", or
+// inherit them by redefining their blocks, for example
+//
+//	{{/* _base.tmpl */}}
+//	<html><body>{{block "content" .}}{{.Body}}{{end}}</body></html>
+//
+//	{{/* blog.tmpl */}}
+//	{{template "base" .}}
+//	{{define "content"}}<article>{{.Body}}</article>{{end}}
+//
+// It will return an error if the layout has the same name with the
+// partial template, for example "base.tmpl" and "_base.tmpl".
+//
+// Each template is parsed on its own copy of the partial templates, so the
+// blocks redefined by one layout does not affect the others.
+//
+func (htmlg *htmlGenerator) setTemplates(content string, layouts map[string]string) (
+	err error,
+) {
+	partials := template.New(prefixPartial)
+
+	for _, name := range layoutNames(layouts) {
+		if !isPartial(name) {
+			continue
+		}
+
+		partialName := strings.TrimPrefix(name, prefixPartial)
+		_, ok := layouts[partialName]
+		if ok {
+			return fmt.Errorf("setTemplates: layout %q conflict with partial template %q",
+				partialName, name)
+		}
+
+		_, err = partials.New(partialName).Parse(layouts[name])
+		if err != nil {
+			return fmt.Errorf("setTemplates: %w", err)
+		}
+	}
+
+	tmpl, err := parseWithPartials(partials, "", content)
+	if err != nil {
+		return fmt.Errorf("setTemplates: %w", err)
+	}
+
+	tmpls := make(map[string]*template.Template, len(layouts))
+
+	for name, content := range layouts {
+		if isPartial(name) {
+			continue
+		}
+		tmpls[name], err = parseWithPartials(partials, name, content)
+		if err != nil {
+			return fmt.Errorf("setTemplates: %w", err)
+		}
+	}
+
+	htmlg.mu.Lock()
+	htmlg.tmpl = tmpl
+	htmlg.layouts = tmpls
+	htmlg.mu.Unlock()
+
+	return nil
+}
+
+//
+// parseWithPartials parse the content as template "name" inside the copy of
+// partial templates.
+//
+func parseWithPartials(partials *template.Template, name, content string) (
+	tmpl *template.Template, err error,
+) {
+	set, err := partials.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	return set.New(name).Parse(content)
+}
+
+//
+// isInTemplateDir return true if the file is inside the template directory.
+//
+func (htmlg *htmlGenerator) isInTemplateDir(file string) bool {
+	if len(htmlg.templateDir) == 0 {
+		return false
+	}
+
+	rel, err := filepath.Rel(htmlg.templateDir, file)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+//
+// layout return the template of layout "name".
+// If the name is empty it will return the default HTML template.
+// The partial template can not be used as layout.
+//
+func (htmlg *htmlGenerator) layout(name string) (
+	tmpl *template.Template, err error,
+) {
+	if len(name) == 0 {
+		return htmlg.tmpl, nil
+	}
+
+	tmpl, ok := htmlg.layouts[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown layout %q", name)
+	}
+
+	return tmpl, nil
+}
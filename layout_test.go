@@ -0,0 +1,255 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestHTMLGenerator_setTemplates(t *testing.T) {
+	layouts := map[string]string{
+		"_header": `<h1>{{.Title}}</h1>`,
+		"_base":   `<main>{{block "content" .}}{{.Body}}{{end}}</main>`,
+		"blog":    `{{template "base" .}}{{define "content"}}<article>{{.Body}}</article>{{end}}`,
+		"page":    `{{template "header" .}}{{template "base" .}}`,
+	}
+	content := `{{template "header" .}}|{{.Body}}`
+
+	cases := []struct {
+		desc    string
+		content string
+		layouts map[string]string
+		layout  string
+		exp     string
+		expErr  string
+	}{{
+		desc:    "With HTML template include partial",
+		content: content,
+		layouts: layouts,
+		exp:     "<h1>T</h1>|B",
+	}, {
+		desc:    "With layout redefine block of partial",
+		content: content,
+		layouts: layouts,
+		layout:  "blog",
+		exp:     "<main><article>B</article></main>",
+	}, {
+		desc:    "With layout use block of partial as is",
+		content: content,
+		layouts: layouts,
+		layout:  "page",
+		exp:     "<h1>T</h1><main>B</main>",
+	}, {
+		desc:    "With partial as layout",
+		content: content,
+		layouts: layouts,
+		layout:  "_header",
+		expErr:  `unknown layout "_header"`,
+	}, {
+		desc:    "With partial name as layout",
+		content: content,
+		layouts: layouts,
+		layout:  "header",
+		expErr:  `unknown layout "header"`,
+	}, {
+		desc:    "With unknown partial",
+		content: `{{template "footer" .}}`,
+		layouts: layouts,
+		expErr:  `html/template::1:11: no such template "footer"`,
+	}, {
+		desc:    "With layout conflict with partial",
+		content: content,
+		layouts: map[string]string{
+			"_base": `<main></main>`,
+			"base":  `<main></main>`,
+		},
+		expErr: `setTemplates: layout "base" conflict with partial template "_base"`,
+	}}
+
+	data := &fileHTML{
+		Title: "T",
+		Body:  "B",
+	}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		var (
+			htmlg = &htmlGenerator{}
+			buf   bytes.Buffer
+		)
+
+		err := htmlg.setTemplates(c.content, c.layouts)
+		if err == nil {
+			tmpl, errLayout := htmlg.layout(c.layout)
+			err = errLayout
+			if err == nil {
+				err = tmpl.Execute(&buf, data)
+			}
+		}
+		if err != nil {
+			test.Assert(t, "error", c.expErr, err.Error(), true)
+			continue
+		}
+
+		test.Assert(t, "output", c.exp, buf.String(), true)
+	}
+}
+
+func TestLoadLayouts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ciigo-layouts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"blog.tmpl":         "blog",
+		"_header.tmpl":      "header",
+		"LANDING.TMPL":      "landing",
+		".hidden.tmpl":      "hidden",
+		"notes.txt":         "notes",
+		"sub/nested.tmpl":   "nested",
+		"html.tmpl.orig":    "orig",
+		"sub/_partial.tmpl": "partial",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(file, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		desc   string
+		dir    string
+		exp    map[string]string
+		expErr string
+	}{{
+		desc: "Without directory",
+	}, {
+		desc: "With directory",
+		dir:  dir,
+		exp: map[string]string{
+			"_header": "header",
+			"blog":    "blog",
+			"LANDING": "landing",
+		},
+	}, {
+		desc:   "With directory not exist",
+		dir:    filepath.Join(dir, "missing"),
+		expErr: "loadLayouts: listLayouts: open " + filepath.Join(dir, "missing") + ": ",
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		got, err := loadLayouts(c.dir)
+		if err != nil {
+			test.Assert(t, "error", true,
+				strings.HasPrefix(err.Error(), c.expErr), true)
+			continue
+		}
+		test.Assert(t, "error", c.expErr, "", true)
+
+		if len(got) == 0 {
+			got = nil
+		}
+
+		test.Assert(t, "layouts", c.exp, got, true)
+	}
+}
+
+func TestConvertWithOptions_layout(t *testing.T) {
+	root, err := ioutil.TempDir("", "ciigo-layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	tmplDir, err := ioutil.TempDir("", "ciigo-layout-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmplDir)
+
+	layouts := map[string]string{
+		"_header.tmpl": `<h1>{{.Title}}</h1>`,
+		"blog.tmpl":    `<article>{{template "header" .}}{{.Body}}</article>`,
+		"html.tmpl":    `<main>{{template "header" .}}{{.Body}}</main>`,
+	}
+	for name, content := range layouts {
+		err = ioutil.WriteFile(filepath.Join(tmplDir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		desc   string
+		markup string
+		exp    string
+		expErr string
+	}{{
+		desc:   "Without layout",
+		markup: "---\ntitle: Page\n---\n\nText.\n",
+		exp:    "<main><h1>Page</h1><p>Text.</p>\n</main>",
+	}, {
+		desc:   "With layout",
+		markup: "---\ntitle: Post\nlayout: blog\n---\n\nText.\n",
+		exp:    "<article><h1>Post</h1><p>Text.</p>\n</article>",
+	}, {
+		desc:   "With partial as layout",
+		markup: "---\ntitle: Post\nlayout: _header\n---\n\nText.\n",
+		expErr: `unknown layout "_header"`,
+	}, {
+		desc:   "With unknown layout",
+		markup: "---\ntitle: Post\nlayout: news\n---\n\nText.\n",
+		expErr: `unknown layout "news"`,
+	}}
+
+	opts := &ConvertOptions{
+		Root:         root,
+		HTMLTemplate: filepath.Join(tmplDir, "html.tmpl"),
+		TemplateDir:  tmplDir,
+	}
+	markupFile := filepath.Join(root, "page.md")
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		err = ioutil.WriteFile(markupFile, []byte(c.markup), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ConvertWithOptions(opts)
+		if err != nil {
+			test.Assert(t, "error", true,
+				strings.Contains(err.Error(), c.expErr), true)
+			continue
+		}
+		test.Assert(t, "error", c.expErr, "", true)
+
+		got, err := ioutil.ReadFile(filepath.Join(root, "page.html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.Assert(t, "page.html", c.exp, string(got), true)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	libhttp "github.com/shuLhan/share/lib/http"
+)
+
+const (
+	pathLiveReload = "/_internal/livereload"
+
+	// liveReloadAll is the event data to reload all pages.
+	liveReloadAll = "*"
+
+	// liveReloadTimeout define the maximum duration of single event
+	// stream, it must be less than the HTTP server write timeout.
+	// The browser will reconnect automatically after the stream closed.
+	liveReloadTimeout = 25 * time.Second
+)
+
+//
+// liveReload broadcast the path of changed pages to the browsers using
+// Server-Sent Events.
+//
+type liveReload struct {
+	mu      sync.Mutex
+	clients map[chan string]struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newLiveReload() *liveReload {
+	return &liveReload{
+		clients: make(map[chan string]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+//
+// broadcast the path of changed page to all connected browsers.
+// The path is either absolute path to the HTML page, for example
+// "/sub/index.html", or liveReloadAll to reload all pages.
+//
+func (lr *liveReload) broadcast(path string) {
+	lr.mu.Lock()
+	for ch := range lr.clients {
+		select {
+		case ch <- path:
+		default:
+			// The client is too slow, skip it.
+		}
+	}
+	lr.mu.Unlock()
+}
+
+//
+// close all event streams.
+//
+func (lr *liveReload) close() {
+	lr.once.Do(func() {
+		close(lr.done)
+	})
+}
+
+//
+// onEvents handle the HTTP request for live reload event stream.
+//
+func (lr *liveReload) onEvents(
+	res http.ResponseWriter, req *http.Request, reqBody []byte,
+) (
+	resBody []byte, err error,
+) {
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("liveReload.onEvents: streaming is not supported")
+	}
+
+	ch := make(chan string, 8)
+
+	lr.mu.Lock()
+	lr.clients[ch] = struct{}{}
+	lr.mu.Unlock()
+
+	defer func() {
+		lr.mu.Lock()
+		delete(lr.clients, ch)
+		lr.mu.Unlock()
+	}()
+
+	res.Header().Set(libhttp.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+	fmt.Fprint(res, "retry: 1000\n\n")
+	flusher.Flush()
+
+	timeout := time.NewTimer(liveReloadTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case path := <-ch:
+			fmt.Fprintf(res, "data: %s\n\n", path)
+			flusher.Flush()
+		case <-req.Context().Done():
+			return nil, nil
+		case <-timeout.C:
+			return nil, nil
+		case <-lr.done:
+			return nil, nil
+		}
+	}
+}
+
+//
+// handler wrap the HTTP handler to inject the live reload script into each
+// HTML page.
+//
+func (lr *liveReload) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet || req.URL.Path == pathLiveReload {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		bufres := &bufferedResponse{
+			header: make(http.Header),
+			code:   http.StatusOK,
+		}
+
+		next.ServeHTTP(bufres, req)
+
+		body := bufres.body.Bytes()
+		contentType := bufres.header.Get(libhttp.HeaderContentType)
+		if strings.HasPrefix(contentType, "text/html") &&
+			len(bufres.header.Get(libhttp.ContentEncoding)) == 0 {
+			body = injectLiveReload(body)
+			bufres.header.Set(libhttp.HeaderContentLength,
+				strconv.Itoa(len(body)))
+		}
+
+		for k, v := range bufres.header {
+			res.Header()[k] = v
+		}
+		res.WriteHeader(bufres.code)
+
+		_, err := res.Write(body)
+		if err != nil {
+			log.Println("ciigo: liveReload: " + err.Error())
+		}
+	})
+}
+
+//
+// injectLiveReload insert the live reload script before the closing body
+// tag, or at the end of HTML if the body tag does not exist.
+//
+func injectLiveReload(html []byte) (out []byte) {
+	script := []byte(templateLiveReload)
+
+	x := bytes.LastIndex(bytes.ToLower(html), []byte("</body>"))
+	if x < 0 {
+		return append(html, script...)
+	}
+
+	out = make([]byte, 0, len(html)+len(script))
+	out = append(out, html[:x]...)
+	out = append(out, script...)
+	out = append(out, html[x:]...)
+
+	return out
+}
+
+//
+// bufferedResponse implement http.ResponseWriter that store the response
+// in memory.
+//
+type bufferedResponse struct {
+	header http.Header
+	body   bytes.Buffer
+	code   int
+}
+
+func (bufres *bufferedResponse) Header() http.Header {
+	return bufres.header
+}
+
+func (bufres *bufferedResponse) Write(b []byte) (int, error) {
+	return bufres.body.Write(b)
+}
+
+func (bufres *bufferedResponse) WriteHeader(code int) {
+	bufres.code = code
+}
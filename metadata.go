@@ -0,0 +1,335 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// metadataScalars contains the metadata keys that must have single value.
+//
+//nolint: gochecknoglobals
+var metadataScalars = []string{
+	metadataAuthor,
+	metadataDate,
+	metadataDraft,
+	metadataLayout,
+	metadataNavTitle,
+	metadataNavTitleAlt,
+	metadataTitle,
+	metadataWeight,
+}
+
+//
+// Metadata contains the metadata of markup file, from the YAML front matter
+// in markdown, the attributes in asciidoc, or the keywords in Org.
+//
+// Each value is one of the following types: string, bool, int64, float64,
+// time.Time, []interface{} for list, or Metadata for nested map.
+//
+// The Metadata is passed to the HTML template as field "Metadata", and its
+// methods can be used to get the value as specific type, for example,
+//
+//	{{.Metadata.String "subtitle"}}
+//	{{range .Metadata.List "tags"}} {{.}} {{end}}
+//	{{with .Metadata.Time "date"}} {{.Format "2 Jan 2006"}} {{end}}
+//	{{with .Metadata.Map "params"}} {{.String "key"}} {{end}}
+//
+type Metadata map[string]interface{}
+
+//
+// newMetadata create Metadata from the metadata returned by Converter,
+// normalizing the type of each value.
+// It will return an error if the value of known key has invalid type, for
+// example the "title" is a list or the "weight" is not a number.
+//
+func newMetadata(raw map[string]interface{}) (md Metadata, err error) {
+	md = make(Metadata, len(raw))
+
+	for k, v := range raw {
+		md[k] = metadataValue(v)
+	}
+
+	err = md.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return md, nil
+}
+
+//
+// metadataValue normalize the type of metadata value.
+//
+func metadataValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string, bool, int64, float64, time.Time, Metadata:
+		return val
+	case int:
+		return int64(val)
+	case int8:
+		return int64(val)
+	case int16:
+		return int64(val)
+	case int32:
+		return int64(val)
+	case uint:
+		return int64(val)
+	case uint8:
+		return int64(val)
+	case uint16:
+		return int64(val)
+	case uint32:
+		return int64(val)
+	case uint64:
+		return int64(val)
+	case float32:
+		return float64(val)
+	case []string:
+		list := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			list = append(list, item)
+		}
+		return list
+	case []interface{}:
+		list := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			list = append(list, metadataValue(item))
+		}
+		return list
+	case map[string]interface{}:
+		md := make(Metadata, len(val))
+		for k, item := range val {
+			md[k] = metadataValue(item)
+		}
+		return md
+	case map[interface{}]interface{}:
+		md := make(Metadata, len(val))
+		for k, item := range val {
+			md[fmt.Sprint(k)] = metadataValue(item)
+		}
+		return md
+	}
+	return fmt.Sprint(v)
+}
+
+//
+// validate the type of known metadata keys.
+//
+func (md Metadata) validate() (err error) {
+	for _, key := range metadataScalars {
+		v, ok := md[key]
+		if !ok {
+			continue
+		}
+		switch v.(type) {
+		case []interface{}:
+			return fmt.Errorf("metadata %q: expecting single value, got list", key)
+		case Metadata:
+			return fmt.Errorf("metadata %q: expecting single value, got map", key)
+		}
+	}
+
+	if md.Has(metadataWeight) {
+		_, ok := md.int(metadataWeight)
+		if !ok {
+			return fmt.Errorf("metadata %q: expecting integer, got %q",
+				metadataWeight, md.String(metadataWeight))
+		}
+	}
+
+	if md.Has(metadataDraft) {
+		_, ok := md.bool(metadataDraft)
+		if !ok {
+			return fmt.Errorf("metadata %q: expecting boolean, got %q",
+				metadataDraft, md.String(metadataDraft))
+		}
+	}
+
+	for _, key := range []string{metadataStylesheet, metadataTags, metadataCategories} {
+		list, ok := md[key].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, item := range list {
+			switch item.(type) {
+			case []interface{}, Metadata:
+				return fmt.Errorf("metadata %q: expecting list of single value", key)
+			}
+		}
+	}
+
+	return nil
+}
+
+//
+// Has return true if the metadata contains the key.
+//
+func (md Metadata) Has(key string) bool {
+	_, ok := md[key]
+	return ok
+}
+
+//
+// String return the value of key as string.
+// The list is joined with ", ", and the date is formatted as "2006-01-02",
+// or as RFC3339 if its has time.
+// It will return empty string if the key does not exist.
+//
+func (md Metadata) String(key string) string {
+	v, ok := md[key]
+	if !ok {
+		return ""
+	}
+	return metadataString(v)
+}
+
+func metadataString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case time.Time:
+		if val.Hour() == 0 && val.Minute() == 0 && val.Second() == 0 {
+			return val.Format(sitemapDate)
+		}
+		return val.Format(time.RFC3339)
+	case []interface{}:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			items = append(items, metadataString(item))
+		}
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+//
+// List return the value of key as list of string.
+// If the value is a string, it will be split by comma, for example the
+// asciidoc attribute ":tags: go, web".
+// The empty items are removed.
+//
+func (md Metadata) List(key string) (list []string) {
+	var items []string
+
+	switch val := md[key].(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		for _, item := range val {
+			items = append(items, metadataString(item))
+		}
+	case string:
+		items = strings.Split(val, ",")
+	default:
+		items = []string{metadataString(val)}
+	}
+
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if len(item) > 0 {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+//
+// Int return the value of key as integer, or 0 if the key does not exist or
+// its value is not a number.
+//
+func (md Metadata) Int(key string) int {
+	n, _ := md.int(key)
+	return n
+}
+
+func (md Metadata) int(key string) (n int, ok bool) {
+	switch val := md[key].(type) {
+	case int64:
+		return int(val), true
+	case float64:
+		return int(val), float64(int(val)) == val
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(val))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+//
+// Float return the value of key as floating number, or 0 if the key does
+// not exist or its value is not a number.
+//
+func (md Metadata) Float(key string) float64 {
+	switch val := md[key].(type) {
+	case int64:
+		return float64(val)
+	case float64:
+		return val
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return f
+	}
+	return 0
+}
+
+//
+// Bool return the value of key as boolean, or false if the key does not
+// exist or its value is not a boolean.
+// The key with empty value, for example the asciidoc attribute ":draft:"
+// or "draft:" in markdown front matter, is true.
+//
+func (md Metadata) Bool(key string) bool {
+	b, _ := md.bool(key)
+	return b
+}
+
+func (md Metadata) bool(key string) (b, ok bool) {
+	switch val := md[key].(type) {
+	case bool:
+		return val, true
+	case string:
+		val = strings.TrimSpace(val)
+		if len(val) == 0 {
+			return true, true
+		}
+		b, err := strconv.ParseBool(val)
+		return b, err == nil
+	}
+	return false, false
+}
+
+//
+// Time return the value of key as time, or nil if the key does not exist
+// or its value is not a date in one of the supported formats.
+//
+func (md Metadata) Time(key string) *time.Time {
+	switch val := md[key].(type) {
+	case time.Time:
+		return &val
+	case string:
+		t, ok := parseDate(val)
+		if ok {
+			return &t
+		}
+	}
+	return nil
+}
+
+//
+// Map return the value of key as Metadata, or nil if the key does not exist
+// or its value is not a map.
+//
+func (md Metadata) Map(key string) Metadata {
+	sub, _ := md[key].(Metadata)
+	return sub
+}
@@ -0,0 +1,48 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestMetadata_Bool(t *testing.T) {
+	cases := []struct {
+		desc string
+		raw  map[string]interface{}
+		exp  bool
+	}{{
+		desc: "Without key",
+		raw:  map[string]interface{}{},
+	}, {
+		desc: "With empty value, as in asciidoc attribute",
+		raw:  map[string]interface{}{metadataDraft: ""},
+		exp:  true,
+	}, {
+		desc: "With null value, as in YAML",
+		raw:  map[string]interface{}{metadataDraft: nil},
+		exp:  true,
+	}, {
+		desc: "With boolean value",
+		raw:  map[string]interface{}{metadataDraft: false},
+	}, {
+		desc: "With string value",
+		raw:  map[string]interface{}{metadataDraft: "true"},
+		exp:  true,
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		md, err := newMetadata(c.raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.Assert(t, "Bool", c.exp, md.Bool(metadataDraft), true)
+	}
+}
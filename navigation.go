@@ -0,0 +1,170 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	metadataNavTitle = "nav_title"
+	metadataWeight   = "weight"
+
+	// metadataNavTitleAlt is the alternative key for metadataNavTitle,
+	// since asciidoc attribute name cannot contains underscore.
+	metadataNavTitleAlt = "nav-title"
+
+	// fileNavigation is the name of file that store the navigation tree,
+	// next to the build cache inside the output directory, so the server
+	// that serve the embedded files can render the navigation on the
+	// pages that are not generated, for example the search page.
+	// The file is hidden and excluded from being served.
+	fileNavigation = ".ciigo-navigation.json"
+)
+
+//
+// NavNode represent a page or a directory in the site navigation tree.
+//
+// The navigation tree is build from the directory hierarchy of markup
+// files, and passed to the HTML template as field "Nav".
+// A directory node use the title and weight of its "index" page, if its
+// exist, otherwise its title is the directory name.
+// The child nodes are sorted by their weight, from lowest to highest, and
+// then by their title.
+//
+type NavNode struct {
+	// Title of the page, from metadata "nav_title" (or "nav-title") or
+	// "title", or the file or directory name if both of them are empty.
+	Title string
+
+	// Path is the absolute URL path to the page, for example
+	// "/sub/page.html", or "/sub/" for directory with index page.
+	// It is empty for directory without index page.
+	Path string
+
+	// Weight of the page, from metadata "weight".
+	Weight int
+
+	// Childs contains the pages and sub directories inside directory.
+	Childs []*NavNode
+
+	name string
+}
+
+//
+// newNavigation build the navigation tree from list of markup files inside
+// the root directory.
+//
+func newNavigation(root string, fileMarkups []*fileMarkup) (nav *NavNode) {
+	nav = &NavNode{}
+	dirs := map[string]*NavNode{
+		".": nav,
+	}
+
+	for _, fmarkup := range fileMarkups {
+		rel, err := filepath.Rel(root, fmarkup.basePath)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		dir, name := path.Split(rel)
+		dir = path.Clean(dir)
+		parent := navDir(dirs, dir)
+
+		if name == "index" {
+			parent.Title = fmarkup.page.navTitle(parent.name)
+			parent.Weight = fmarkup.page.Weight
+			parent.Path = "/"
+			if dir != "." {
+				parent.Path += dir + "/"
+			}
+			continue
+		}
+
+		parent.Childs = append(parent.Childs, &NavNode{
+			Title:  fmarkup.page.navTitle(name),
+			Path:   "/" + rel + ".html",
+			Weight: fmarkup.page.Weight,
+			name:   name,
+		})
+	}
+
+	nav.sort()
+
+	return nav
+}
+
+//
+// loadNavigation load the navigation tree from JSON.
+//
+func loadNavigation(content []byte) (nav *NavNode, err error) {
+	nav = &NavNode{}
+
+	err = json.Unmarshal(content, nav)
+	if err != nil {
+		return nil, fmt.Errorf("loadNavigation: %w", err)
+	}
+
+	return nav, nil
+}
+
+//
+// navDir return the node of directory "dir", create it and its parents if
+// its not exist.
+//
+func navDir(dirs map[string]*NavNode, dir string) (node *NavNode) {
+	node, ok := dirs[dir]
+	if ok {
+		return node
+	}
+
+	parentDir, name := path.Split(dir)
+	parent := navDir(dirs, path.Clean(parentDir))
+
+	node = &NavNode{
+		Title: name,
+		name:  name,
+	}
+	parent.Childs = append(parent.Childs, node)
+	dirs[dir] = node
+
+	return node
+}
+
+//
+// hash return the hash of navigation tree, used to detect changes on
+// navigation.
+//
+func (node *NavNode) hash() string {
+	b, err := json.Marshal(node)
+	if err != nil {
+		return ""
+	}
+	return contentHash(b)
+}
+
+//
+// sort the child nodes recursively by weight and title.
+//
+func (node *NavNode) sort() {
+	sort.SliceStable(node.Childs, func(x, y int) bool {
+		a, b := node.Childs[x], node.Childs[y]
+		if a.Weight != b.Weight {
+			return a.Weight < b.Weight
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.name < b.name
+	})
+	for _, child := range node.Childs {
+		child.sort()
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+//
+// navLines return the title and path of each node in navigation tree, one
+// line per node, indented by its depth.
+//
+func navLines(node *NavNode, depth int) (lines []string) {
+	for _, child := range node.Childs {
+		lines = append(lines, strings.Repeat("\t", depth)+
+			child.Title+" "+child.Path)
+		lines = append(lines, navLines(child, depth+1)...)
+	}
+	return lines
+}
+
+func TestNewNavigation(t *testing.T) {
+	newPage := func(name string, page pageInfo) *fileMarkup {
+		return &fileMarkup{
+			basePath: filepath.Join("content", filepath.FromSlash(name)),
+			page:     page,
+		}
+	}
+
+	cases := []struct {
+		desc     string
+		pages    []*fileMarkup
+		expTitle string
+		expPath  string
+		exp      []string
+	}{{
+		desc: "With weight",
+		pages: []*fileMarkup{
+			newPage("a", pageInfo{Title: "A", Weight: 2}),
+			newPage("b", pageInfo{Title: "B", Weight: 1}),
+			newPage("c", pageInfo{Title: "C"}),
+			newPage("d", pageInfo{Title: "D", Weight: -1}),
+		},
+		exp: []string{
+			"D /d.html",
+			"C /c.html",
+			"B /b.html",
+			"A /a.html",
+		},
+	}, {
+		desc: "With the same weight",
+		pages: []*fileMarkup{
+			newPage("a", pageInfo{Title: "Beta"}),
+			newPage("b", pageInfo{Title: "Zeta", NavTitle: "Alpha"}),
+			newPage("c", pageInfo{}),
+		},
+		exp: []string{
+			"Alpha /b.html",
+			"Beta /a.html",
+			"c /c.html",
+		},
+	}, {
+		desc: "With directories",
+		pages: []*fileMarkup{
+			newPage("index", pageInfo{Title: "Home"}),
+			newPage("page", pageInfo{Title: "Page"}),
+			newPage("sub/index", pageInfo{Title: "Sub", Weight: -1}),
+			newPage("sub/page", pageInfo{Title: "Sub page"}),
+			newPage("noindex/deep/page", pageInfo{Title: "Deep page"}),
+		},
+		expTitle: "Home",
+		expPath:  "/",
+		exp: []string{
+			"Sub /sub/",
+			"\tSub page /sub/page.html",
+			"Page /page.html",
+			"noindex ",
+			"\tdeep ",
+			"\t\tDeep page /noindex/deep/page.html",
+		},
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		nav := newNavigation("content", c.pages)
+
+		test.Assert(t, "Title", c.expTitle, nav.Title, true)
+		test.Assert(t, "Path", c.expPath, nav.Path, true)
+		test.Assert(t, "Childs", c.exp, navLines(nav, 0), true)
+
+		b, err := json.Marshal(nav)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := loadNavigation(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.Assert(t, "loadNavigation", c.exp, navLines(got, 0), true)
+	}
+}
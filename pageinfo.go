@@ -0,0 +1,128 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"strings"
+	"time"
+)
+
+//
+// dateLayouts contains the list of supported layouts for metadata "date".
+//
+//nolint: gochecknoglobals
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+//
+// pageInfo contains the page metadata that is used to build the navigation
+// tree, the sitemap, the feeds, and the taxonomy pages.
+// It is stored in the build cache, so the page that is not converted can
+// still be included.
+//
+type pageInfo struct {
+	Title    string `json:"title,omitempty"`
+	NavTitle string `json:"nav_title,omitempty"`
+	Weight   int    `json:"weight,omitempty"`
+	Date     string `json:"date,omitempty"`
+	Author   string `json:"author,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+	Draft    bool   `json:"draft,omitempty"`
+
+	Tags       []string `json:"tags,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+func newPageInfo(md Metadata) (page pageInfo) {
+	page = pageInfo{
+		Title:      md.String(metadataTitle),
+		NavTitle:   md.String(metadataNavTitle),
+		Weight:     md.Int(metadataWeight),
+		Date:       md.String(metadataDate),
+		Author:     md.String(metadataAuthor),
+		Draft:      md.Bool(metadataDraft),
+		Tags:       md.List(metadataTags),
+		Categories: md.List(metadataCategories),
+	}
+	if len(page.NavTitle) == 0 {
+		page.NavTitle = md.String(metadataNavTitleAlt)
+	}
+	return page
+}
+
+//
+// navTitle return the title for navigation, or "def" if the page does not
+// have title.
+//
+func (page pageInfo) navTitle(def string) string {
+	if len(page.NavTitle) > 0 {
+		return page.NavTitle
+	}
+	if len(page.Title) > 0 {
+		return page.Title
+	}
+	return def
+}
+
+//
+// time return the page date as time.Time.
+// It will return false if the page does not have date or the date format
+// is not supported.
+//
+func (page pageInfo) time() (t time.Time, ok bool) {
+	return parseDate(page.Date)
+}
+
+//
+// isPublished return true if the page is not a draft and its date, if any,
+// is not after "now".
+//
+func (page pageInfo) isPublished(now time.Time) bool {
+	if page.Draft {
+		return false
+	}
+	t, ok := page.time()
+	return !ok || !t.After(now)
+}
+
+//
+// parseDate parse the date using one of the dateLayouts.
+// The Org timestamp, for example "<2020-08-01 Sat>", is also supported.
+//
+func parseDate(date string) (t time.Time, ok bool) {
+	date = strings.TrimSpace(strings.Trim(strings.TrimSpace(date), "<>[]"))
+	if len(date) == 0 {
+		return t, false
+	}
+
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, date)
+		if err == nil {
+			return t, true
+		}
+	}
+
+	// Try to parse the date without the rest of timestamp.
+	if len(date) > 10 {
+		t, err := time.Parse("2006-01-02", date[:10])
+		if err == nil {
+			return t, true
+		}
+	}
+
+	return t, false
+}
@@ -0,0 +1,717 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//nolint: gochecknoglobals
+var htmlBlockTagRE = regexp.MustCompile(
+	`(?i)</?(blockquote|br|dd|div|dl|dt|h[1-6]|hr|li|ol|p|pre|table|td|th|tr|ul)\b[^>]*>`)
+
+const (
+	// fileSearchIndex is the name of hidden file that store the search
+	// index, inside the output directory, next to the build cache.
+	fileSearchIndex = ".ciigo-search-index.json"
+
+	// searchTitleBoost define the weight of word in the page title,
+	// relative to the word in the page content.
+	searchTitleBoost = 5
+
+	// searchPrefixWeight define the weight of word in page that start
+	// with the word in query, relative to the word that equal with it.
+	searchPrefixWeight = 0.5
+
+	// searchWordMin define the minimum number of characters in word to
+	// be indexed and searched.
+	searchWordMin = 2
+
+	searchSnippetLen = 60
+	searchSnippetMax = 3
+
+	// searchLimitDefault define the default number of results per page.
+	searchLimitDefault = 10
+
+	// searchLimitMax define the maximum number of results per page.
+	searchLimitMax = 100
+)
+
+//
+// SearchResult contains the page that match with the search query.
+//
+type SearchResult struct {
+	// Path is the absolute URL path to the page.
+	Path string `json:"path"`
+
+	// Title of the page.
+	Title string `json:"title"`
+
+	// Date, Author, Summary, and Tags of the page, from its metadata.
+	Date    string   `json:"date,omitempty"`
+	Author  string   `json:"author,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+
+	// Score of the page, the higher the score the more relevant the page
+	// to the query.
+	Score float64 `json:"score"`
+
+	// Snippets contains parts of the page text around the words in query.
+	Snippets []string `json:"snippets"`
+
+	// Highlights contains the Snippets as HTML, with the words that
+	// match with the query wrapped in "<mark>" element.
+	Highlights []template.HTML `json:"highlights"`
+
+	// text contains the text of the page, to generate the Snippets
+	// only for the results in the response page.
+	text string
+}
+
+//
+// SearchResponse contains single page of search results.
+// It is passed to the search template and returned by the JSON search
+// endpoint.
+//
+type SearchResponse struct {
+	// Query is the search query.
+	Query string `json:"query"`
+
+	// Total is the number of pages that match with the query.
+	Total int `json:"total"`
+
+	// Page is the current page of results, start from 1.
+	Page int `json:"page"`
+
+	// Limit is the maximum number of results per page.
+	Limit int `json:"limit"`
+
+	// Results contains the search results on current page.
+	Results []*SearchResult `json:"results"`
+}
+
+//
+// newSearchResponse create the response for the results on "page", with
+// "limit" results per page.
+// If page is less than 1, it will default to 1.
+// If limit is less than 1, it will default to searchLimitDefault, and it
+// will never greater than searchLimitMax.
+// The snippets and highlights are generated only for the results on the
+// page.
+//
+func newSearchResponse(query string, page, limit int, results []*SearchResult) (
+	res *SearchResponse,
+) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = searchLimitDefault
+	}
+	if limit > searchLimitMax {
+		limit = searchLimitMax
+	}
+
+	res = &SearchResponse{
+		Query: query,
+		Total: len(results),
+		Page:  page,
+		Limit: limit,
+	}
+
+	start := (page - 1) * limit
+	if start > len(results) {
+		start = len(results)
+	}
+	end := start + limit
+	if end > len(results) {
+		end = len(results)
+	}
+
+	res.Results = results[start:end]
+	if res.Results == nil {
+		res.Results = make([]*SearchResult, 0)
+	}
+
+	tokens := searchTokens(query)
+	for _, result := range res.Results {
+		if len(result.text) > 0 {
+			result.Snippets = searchSnippets(result.text, tokens)
+		}
+		result.Highlights = make([]template.HTML, 0, len(result.Snippets))
+		for _, snippet := range result.Snippets {
+			result.Highlights = append(result.Highlights,
+				searchHighlight(snippet, tokens))
+		}
+	}
+
+	return res
+}
+
+//
+// Pages return the number of result pages.
+//
+func (res *SearchResponse) Pages() int {
+	return (res.Total + res.Limit - 1) / res.Limit
+}
+
+//
+// PrevPage return the number of previous page, or 0 if the current page is
+// the first page.
+//
+func (res *SearchResponse) PrevPage() int {
+	if res.Page <= 1 {
+		return 0
+	}
+	return res.Page - 1
+}
+
+//
+// NextPage return the number of next page, or 0 if the current page is the
+// last page.
+//
+func (res *SearchResponse) NextPage() int {
+	if res.Page >= res.Pages() {
+		return 0
+	}
+	return res.Page + 1
+}
+
+//
+// searchDoc represent a page in the search index.
+//
+type searchDoc struct {
+	Path    string   `json:"path"`
+	Title   string   `json:"title"`
+	Date    string   `json:"date,omitempty"`
+	Author  string   `json:"author,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Text    string   `json:"text"`
+
+	// terms contains the weight of each word in the page.
+	terms map[string]int
+}
+
+//
+// equal return true if both documents have the same fields.
+//
+func (doc *searchDoc) equal(other *searchDoc) bool {
+	if doc.Path != other.Path || doc.Title != other.Title ||
+		doc.Date != other.Date || doc.Author != other.Author ||
+		doc.Summary != other.Summary || doc.Text != other.Text {
+		return false
+	}
+	if len(doc.Tags) != len(other.Tags) {
+		return false
+	}
+	for x, tag := range doc.Tags {
+		if tag != other.Tags[x] {
+			return false
+		}
+	}
+	return true
+}
+
+//
+// searchIndex is an inverted index of words in the rendered pages.
+//
+// The pages, with their title and text, are stored in file
+// ".ciigo-search-index.json" inside the output directory, so the index can
+// be updated incrementally and embedded by Generate.
+// The inverted index is built in memory when the index is loaded.
+//
+type searchIndex struct {
+	mu        sync.RWMutex
+	docs      map[string]*searchDoc
+	postings  map[string]map[string]int
+	isChanged bool
+
+	// terms contains the keys of postings sorted in ascending order, to
+	// find the terms with specific prefix using binary search.
+	// It is rebuilt on the next search if isSorted is false.
+	terms    []string
+	isSorted bool
+}
+
+//
+// searchIndexFile is the format of search index file.
+//
+type searchIndexFile struct {
+	Docs []*searchDoc `json:"docs"`
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		docs:     make(map[string]*searchDoc),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+//
+// loadSearchIndex create the search index from the content of search index
+// file.
+//
+func loadSearchIndex(content []byte) (idx *searchIndex, err error) {
+	var file searchIndexFile
+
+	err = json.Unmarshal(content, &file)
+	if err != nil {
+		return nil, fmt.Errorf("loadSearchIndex: %w", err)
+	}
+
+	idx = newSearchIndex()
+	for _, doc := range file.Docs {
+		idx.add(doc)
+	}
+
+	return idx, nil
+}
+
+//
+// readSearchIndex read the search index from file.
+// If the file does not exist or invalid, it will return an empty index.
+//
+func readSearchIndex(file string) (idx *searchIndex) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("ciigo: readSearchIndex: %s", err)
+		}
+		return newSearchIndex()
+	}
+
+	idx, err = loadSearchIndex(content)
+	if err != nil {
+		log.Printf("ciigo: invalid search index %s: %s", file, err)
+		return newSearchIndex()
+	}
+
+	return idx
+}
+
+//
+// add the document into index, replacing the document with the same path.
+// The caller must hold the lock.
+//
+func (idx *searchIndex) add(doc *searchDoc) {
+	idx.del(doc.Path)
+
+	doc.terms = make(map[string]int)
+	for _, token := range searchTokens(doc.Title) {
+		doc.terms[token] += searchTitleBoost
+	}
+	for _, token := range searchTokens(doc.Text) {
+		doc.terms[token]++
+	}
+
+	for token, weight := range doc.terms {
+		posting := idx.postings[token]
+		if posting == nil {
+			posting = make(map[string]int)
+			idx.postings[token] = posting
+			idx.isSorted = false
+		}
+		posting[doc.Path] = weight
+	}
+
+	idx.docs[doc.Path] = doc
+}
+
+//
+// del remove the document from index.
+// The caller must hold the lock.
+//
+func (idx *searchIndex) del(path string) {
+	doc := idx.docs[path]
+	if doc == nil {
+		return
+	}
+	for token := range doc.terms {
+		posting := idx.postings[token]
+		delete(posting, path)
+		if len(posting) == 0 {
+			delete(idx.postings, token)
+			idx.isSorted = false
+		}
+	}
+	delete(idx.docs, path)
+}
+
+//
+// has return true if the page with URL path is exist in the index.
+//
+func (idx *searchIndex) has(path string) bool {
+	idx.mu.RLock()
+	_, ok := idx.docs[path]
+	idx.mu.RUnlock()
+	return ok
+}
+
+//
+// set the metadata and the text of HTML body of the page with URL path.
+//
+func (idx *searchIndex) set(path string, page pageInfo, body string) {
+	doc := &searchDoc{
+		Path:    path,
+		Title:   page.Title,
+		Date:    page.Date,
+		Author:  page.Author,
+		Summary: page.Summary,
+		Tags:    page.Tags,
+		Text:    htmlText(body),
+	}
+
+	idx.mu.Lock()
+	old := idx.docs[path]
+	if old == nil || !old.equal(doc) {
+		idx.add(doc)
+		idx.isChanged = true
+	}
+	idx.mu.Unlock()
+}
+
+//
+// prune remove the pages that does not exist in "paths" from index.
+//
+func (idx *searchIndex) prune(paths map[string]struct{}) {
+	idx.mu.Lock()
+	for path := range idx.docs {
+		_, ok := paths[path]
+		if !ok {
+			idx.del(path)
+			idx.isChanged = true
+		}
+	}
+	idx.mu.Unlock()
+}
+
+//
+// save the index into file, only if its has been changed since loaded or
+// the file does not exist.
+//
+func (idx *searchIndex) save(file string) (err error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !idx.isChanged {
+		_, err = os.Stat(file)
+		if err == nil {
+			return nil
+		}
+	}
+
+	b, err := json.Marshal(idx.content())
+	if err != nil {
+		return fmt.Errorf("searchIndex.save: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return fmt.Errorf("searchIndex.save: %w", err)
+	}
+
+	err = ioutil.WriteFile(file, b, 0644)
+	if err != nil {
+		return fmt.Errorf("searchIndex.save: %w", err)
+	}
+
+	idx.isChanged = false
+
+	return nil
+}
+
+//
+// export return the search index for the static search.
+// The words of each page are stored with their weight, the same as the
+// postings, so the script does not need to index the text of all pages.
+//
+func (idx *searchIndex) export() (out *staticSearchIndex) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	content := idx.content()
+
+	out = &staticSearchIndex{
+		Docs:  make([]*staticSearchDoc, 0, len(content.Docs)),
+		Terms: make(map[string][]int, len(idx.postings)),
+	}
+
+	for x, doc := range content.Docs {
+		out.Docs = append(out.Docs, &staticSearchDoc{
+			Path:    doc.Path,
+			Title:   doc.Title,
+			Summary: doc.Summary,
+			Text:    truncateText(doc.Text, summaryLength),
+		})
+		for term, weight := range doc.terms {
+			out.Terms[term] = append(out.Terms[term], x, weight)
+		}
+	}
+
+	return out
+}
+
+//
+// content return the documents in index sorted by path.
+// The caller must hold the lock.
+//
+func (idx *searchIndex) content() (content *searchIndexFile) {
+	content = &searchIndexFile{
+		Docs: make([]*searchDoc, 0, len(idx.docs)),
+	}
+	for _, doc := range idx.docs {
+		content.Docs = append(content.Docs, doc)
+	}
+	sort.Slice(content.Docs, func(x, y int) bool {
+		return content.Docs[x].Path < content.Docs[y].Path
+	})
+	return content
+}
+
+//
+// sortTerms rebuild the sorted terms from the postings.
+// The caller must hold the write lock.
+//
+func (idx *searchIndex) sortTerms() {
+	idx.terms = idx.terms[:0]
+	for term := range idx.postings {
+		idx.terms = append(idx.terms, term)
+	}
+	sort.Strings(idx.terms)
+	idx.isSorted = true
+}
+
+//
+// search the pages that contains all of the words in query.
+// A word in query match with the word in page if its equal, or with lower
+// score if its the prefix of word in page.
+// The results are sorted by score, from the highest.
+// The Snippets of results are not set, they are generated by
+// newSearchResponse for the results on requested page.
+//
+func (idx *searchIndex) search(query string) (results []*SearchResult) {
+	tokens := searchTokens(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	for !idx.isSorted {
+		idx.mu.RUnlock()
+		idx.mu.Lock()
+		if !idx.isSorted {
+			idx.sortTerms()
+		}
+		idx.mu.Unlock()
+		idx.mu.RLock()
+	}
+	defer idx.mu.RUnlock()
+
+	var scores map[string]float64
+
+	for _, token := range tokens {
+		tokenScores := make(map[string]float64)
+
+		for path, weight := range idx.postings[token] {
+			tokenScores[path] += float64(weight)
+		}
+
+		// The terms that start with token are sorted right after
+		// the token itself.
+		x := sort.SearchStrings(idx.terms, token)
+		for ; x < len(idx.terms); x++ {
+			term := idx.terms[x]
+			if !strings.HasPrefix(term, token) {
+				break
+			}
+			if term == token {
+				continue
+			}
+			for path, weight := range idx.postings[term] {
+				tokenScores[path] += searchPrefixWeight * float64(weight)
+			}
+		}
+
+		if scores == nil {
+			scores = tokenScores
+			continue
+		}
+		for path, score := range scores {
+			tokenScore, ok := tokenScores[path]
+			if !ok {
+				delete(scores, path)
+				continue
+			}
+			scores[path] = score + tokenScore
+		}
+	}
+
+	for path, score := range scores {
+		doc := idx.docs[path]
+		results = append(results, &SearchResult{
+			Path:    doc.Path,
+			Title:   doc.Title,
+			Date:    doc.Date,
+			Author:  doc.Author,
+			Summary: doc.Summary,
+			Tags:    doc.Tags,
+			Score:   score,
+			text:    doc.Text,
+		})
+	}
+
+	sort.Slice(results, func(x, y int) bool {
+		if results[x].Score != results[y].Score {
+			return results[x].Score > results[y].Score
+		}
+		return results[x].Path < results[y].Path
+	})
+
+	return results
+}
+
+//
+// searchTokens split the text into lower case words.
+// Word with characters less than searchWordMin is ignored.
+//
+func searchTokens(text string) (tokens []string) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if len([]rune(word)) >= searchWordMin {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+//
+// searchSnippets return the parts of text around the first occurrence of
+// each token.
+//
+func searchSnippets(text string, tokens []string) (snippets []string) {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+
+	for _, token := range tokens {
+		x := runesIndex(lower, []rune(token))
+		if x < 0 {
+			continue
+		}
+
+		start := x - searchSnippetLen
+		if start < 0 {
+			start = 0
+		}
+		end := x + len([]rune(token)) + searchSnippetLen
+		if end > len(runes) {
+			end = len(runes)
+		}
+
+		snippets = append(snippets, string(runes[start:end]))
+		if len(snippets) == searchSnippetMax {
+			break
+		}
+	}
+
+	return snippets
+}
+
+//
+// searchHighlight escape the snippet as HTML and wrap each word that start
+// with one of the tokens in "<mark>" element.
+//
+func searchHighlight(snippet string, tokens []string) template.HTML {
+	var (
+		out   strings.Builder
+		runes = []rune(snippet)
+		start = 0
+	)
+
+	isWordRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	for x := 0; x < len(runes); {
+		if !isWordRune(runes[x]) {
+			x++
+			continue
+		}
+
+		end := x
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+
+		word := strings.ToLower(string(runes[x:end]))
+		for _, token := range tokens {
+			if !strings.HasPrefix(word, token) {
+				continue
+			}
+			out.WriteString(html.EscapeString(string(runes[start:x])))
+			out.WriteString("<mark>")
+			out.WriteString(html.EscapeString(string(runes[x:end])))
+			out.WriteString("</mark>")
+			start = end
+			break
+		}
+
+		x = end
+	}
+	out.WriteString(html.EscapeString(string(runes[start:])))
+
+	return template.HTML(out.String()) //nolint: gosec
+}
+
+//
+// runesIndex return the index of the first occurrence of sub in s, or -1 if
+// sub is not present in s.
+//
+func runesIndex(s, sub []rune) int {
+	for x := 0; x+len(sub) <= len(s); x++ {
+		match := true
+		for y := range sub {
+			if s[x+y] != sub[y] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return x
+		}
+	}
+	return -1
+}
+
+//
+// htmlText return the text of HTML without tags and with white spaces
+// collapsed.
+// The block tags are replaced with space, so the words in different blocks
+// are separated.
+//
+func htmlText(body string) string {
+	text := htmlBlockTagRE.ReplaceAllString(body, " ")
+	text = tocTagRE.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,200 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestSearchIndex_search(t *testing.T) {
+	idx := newSearchIndex()
+	idx.add(&searchDoc{Path: "/a.html", Title: "A", Text: "golang go"})
+	idx.add(&searchDoc{Path: "/b.html", Title: "B", Text: "gopher"})
+	idx.add(&searchDoc{Path: "/c.html", Title: "C", Text: "rust"})
+
+	cases := []struct {
+		desc  string
+		query string
+		exp   []string
+	}{{
+		desc:  "With exact and prefix match",
+		query: "go",
+		exp:   []string{"/a.html", "/b.html"},
+	}, {
+		desc:  "With prefix match only",
+		query: "goph",
+		exp:   []string{"/b.html"},
+	}, {
+		desc:  "With all tokens must match",
+		query: "go rust",
+	}, {
+		desc:  "With unknown word",
+		query: "python",
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		var got []string
+		for _, res := range idx.search(c.query) {
+			got = append(got, res.Path)
+		}
+
+		test.Assert(t, "paths", c.exp, got, true)
+	}
+
+	t.Log("After the document is deleted")
+
+	idx.del("/b.html")
+
+	test.Assert(t, "search", 0, len(idx.search("goph")), true)
+}
+
+func TestSearchIndex_export(t *testing.T) {
+	idx := newSearchIndex()
+	idx.add(&searchDoc{Path: "/b.html", Title: "Go", Text: "go gopher"})
+	idx.add(&searchDoc{Path: "/a.html", Title: "A", Summary: "Sum",
+		Text: "golang"})
+
+	exp := &staticSearchIndex{
+		Docs: []*staticSearchDoc{{
+			Path:    "/a.html",
+			Title:   "A",
+			Summary: "Sum",
+			Text:    "golang",
+		}, {
+			Path:  "/b.html",
+			Title: "Go",
+			Text:  "go gopher",
+		}},
+		Terms: map[string][]int{
+			"golang": {0, 1},
+			"go":     {1, searchTitleBoost + 1},
+			"gopher": {1, 1},
+		},
+	}
+
+	test.Assert(t, "export", exp, idx.export(), true)
+}
+
+func TestNewSearchResponse(t *testing.T) {
+	newResults := func(n int) (results []*SearchResult) {
+		for x := 0; x < n; x++ {
+			results = append(results, &SearchResult{
+				Path: fmt.Sprintf("/%d.html", x),
+				text: "a go page",
+			})
+		}
+		return results
+	}
+
+	cases := []struct {
+		desc     string
+		page     int
+		limit    int
+		total    int
+		expPage  int
+		expLimit int
+		expPaths []string
+		expPages int
+		expPrev  int
+		expNext  int
+	}{{
+		desc:     "With page 0",
+		page:     0,
+		limit:    2,
+		total:    5,
+		expPage:  1,
+		expLimit: 2,
+		expPaths: []string{"/0.html", "/1.html"},
+		expPages: 3,
+		expNext:  2,
+	}, {
+		desc:     "With last page",
+		page:     3,
+		limit:    2,
+		total:    5,
+		expPage:  3,
+		expLimit: 2,
+		expPaths: []string{"/4.html"},
+		expPages: 3,
+		expPrev:  2,
+	}, {
+		desc:     "With page past the end",
+		page:     4,
+		limit:    2,
+		total:    5,
+		expPage:  4,
+		expLimit: 2,
+		expPaths: []string{},
+		expPages: 3,
+		expPrev:  3,
+	}, {
+		desc:     "With limit 0",
+		page:     1,
+		limit:    0,
+		total:    12,
+		expPage:  1,
+		expLimit: searchLimitDefault,
+		expPaths: []string{"/0.html", "/1.html", "/2.html", "/3.html",
+			"/4.html", "/5.html", "/6.html", "/7.html", "/8.html",
+			"/9.html"},
+		expPages: 2,
+		expNext:  2,
+	}, {
+		desc:     "With limit greater than maximum",
+		page:     1,
+		limit:    searchLimitMax + 1,
+		total:    1,
+		expPage:  1,
+		expLimit: searchLimitMax,
+		expPaths: []string{"/0.html"},
+		expPages: 1,
+	}, {
+		desc:     "Without results",
+		page:     1,
+		limit:    2,
+		expPage:  1,
+		expLimit: 2,
+		expPaths: []string{},
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		results := newResults(c.total)
+
+		res := newSearchResponse("go", c.page, c.limit, results)
+
+		paths := make([]string, 0, len(res.Results))
+		for _, result := range res.Results {
+			paths = append(paths, result.Path)
+		}
+
+		test.Assert(t, "Total", c.total, res.Total, true)
+		test.Assert(t, "Page", c.expPage, res.Page, true)
+		test.Assert(t, "Limit", c.expLimit, res.Limit, true)
+		test.Assert(t, "paths", c.expPaths, paths, true)
+		test.Assert(t, "Pages", c.expPages, res.Pages(), true)
+		test.Assert(t, "PrevPage", c.expPrev, res.PrevPage(), true)
+		test.Assert(t, "NextPage", c.expNext, res.NextPage(), true)
+
+		// Only the results on the page have snippets.
+		for _, result := range results {
+			isOnPage := false
+			for _, path := range paths {
+				if path == result.Path {
+					isOnPage = true
+					break
+				}
+			}
+			test.Assert(t, "has snippets "+result.Path, isOnPage,
+				len(result.Snippets) > 0, true)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+//
+// ServeOptions define the options to use on ServeWithOptions function.
+//
+type ServeOptions struct {
+	ConvertOptions
+
+	// Address define the listen address for HTTP server, using "ip:port"
+	// format.
+	// This field is optional, default to ":8080".
+	Address string
+
+	// IsDevelopment if its true, the server will convert all markup
+	// files, serve the files directly from file system, and watch any
+	// changes on markup files and HTML template.
+	IsDevelopment bool
+}
+
+//
+// init set the default value for each empty field.
+// The OutputDir is cleared, since the server serve and write the HTML files
+// inside the Root directory.
+//
+func (opts *ServeOptions) init() (err error) {
+	opts.OutputDir = ""
+
+	err = opts.ConvertOptions.init()
+	if err != nil {
+		return err
+	}
+
+	if len(opts.Address) == 0 {
+		opts.Address = defAddress
+	}
+
+	return nil
+}
@@ -6,59 +6,89 @@ package ciigo
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/shuLhan/share/lib/debug"
 	libhttp "github.com/shuLhan/share/lib/http"
 	libio "github.com/shuLhan/share/lib/io"
 )
 
+const (
+	// pathInternal is the prefix of URL path for ciigo server endpoints.
+	pathInternal = "/_internal/"
+
+	pathSearch     = pathInternal + "search"
+	pathSearchJSON = pathInternal + "search.json"
+)
+
 //
 // server contains the HTTP server.
 //
 type server struct {
 	http        *libhttp.Server
-	opts        *libhttp.ServerOptions
+	httpOpts    *libhttp.ServerOptions
+	opts        *ServeOptions
 	htmlg       *htmlGenerator
 	fileMarkups []*fileMarkup
 	dw          *libio.DirWatcher
+	tmplWatcher *libio.Watcher
+	liveReload  *liveReload
+
+	// tmplSearchWatcher watch the changes on search template.
+	tmplSearchWatcher *libio.Watcher
+
+	// tmplDirWatcher watch the changes on all files inside the template
+	// directory.
+	tmplDirWatcher *libio.DirWatcher
+
+	// mu serialize the changes on fileMarkups and the conversion
+	// triggered by the watchers.
+	mu sync.Mutex
+
+	// memfsMu guard the memory file system in development mode, where
+	// the HTTP requests read it and the watchers remove its nodes.
+	// If both locks are needed, the mu must be acquired first.
+	memfsMu sync.RWMutex
 }
 
 //
-// newServer create an HTTP server to serve HTML files in directory "root".
+// newServer create an HTTP server to serve HTML files in directory
+// opts.Root.
 //
-// The address parameter is optional, if not set its default to ":8080".
-// The htmlTemplate parameter is optional, if not set its default to
-// embedded HTML template.
-//
-func newServer(root, address, htmlTemplate string) (srv *server) {
-	var err error
+func newServer(opts *ServeOptions) (srv *server, err error) {
+	excludes := append(defaultExcludes(), opts.Exclude...)
 
 	srv = &server{
-		opts: &libhttp.ServerOptions{
-			Address:     address,
-			Root:        root,
-			Excludes:    defExcludes,
-			Development: debug.Value > 0,
+		httpOpts: &libhttp.ServerOptions{
+			Address:     opts.Address,
+			Root:        opts.Root,
+			Excludes:    excludes,
+			Development: opts.IsDevelopment,
 		},
+		opts: opts,
 	}
 
-	srv.http, err = libhttp.NewServer(srv.opts)
+	srv.http, err = libhttp.NewServer(srv.httpOpts)
 	if err != nil {
-		log.Fatal("ciigo: libhttp.NewServer: " + err.Error())
+		return nil, fmt.Errorf("newServer: %w", err)
 	}
 
 	epInSearch := &libhttp.Endpoint{
 		Method:       libhttp.RequestMethodGet,
-		Path:         "/_internal/search",
+		Path:         pathSearch,
 		RequestType:  libhttp.RequestTypeQuery,
 		ResponseType: libhttp.ResponseTypeHTML,
 		Call:         srv.onSearch,
@@ -66,44 +96,133 @@ func newServer(root, address, htmlTemplate string) (srv *server) {
 
 	err = srv.http.RegisterEndpoint(epInSearch)
 	if err != nil {
-		log.Fatal("ciigo: " + err.Error())
+		return nil, fmt.Errorf("newServer: %w", err)
+	}
+
+	epInSearchJSON := &libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         pathSearchJSON,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         srv.onSearchJSON,
 	}
 
-	srv.initHTMLGenerator(htmlTemplate)
+	err = srv.http.RegisterEndpoint(epInSearchJSON)
+	if err != nil {
+		return nil, fmt.Errorf("newServer: %w", err)
+	}
 
-	if srv.opts.Development {
-		srv.fileMarkups = listFileMarkups(root)
-		srv.htmlg.convertFileMarkups(srv.fileMarkups, false)
+	err = srv.initHTMLGenerator()
+	if err != nil {
+		return nil, fmt.Errorf("newServer: %w", err)
 	}
 
-	return srv
+	if srv.opts.IsDevelopment {
+		srv.http.Handler = srv.lockMemfs(srv.http.Handler)
+
+		err = srv.initLiveReload()
+		if err != nil {
+			return nil, fmt.Errorf("newServer: %w", err)
+		}
+
+		srv.fileMarkups, err = listFileMarkups(opts.Root, &opts.ConvertOptions)
+		if err != nil {
+			return nil, fmt.Errorf("newServer: %w", err)
+		}
+
+		_, err = srv.htmlg.convertFileMarkups(srv.fileMarkups, false)
+		if err != nil {
+			return nil, fmt.Errorf("newServer: %w", err)
+		}
+
+		err = srv.updatePublished()
+		if err != nil {
+			return nil, fmt.Errorf("newServer: %w", err)
+		}
+	}
+
+	return srv, nil
 }
 
 //
-// start the web server.
+// start the web server and block until the server is stopped or the ctx is
+// done.
+// If the ctx is done, the server will stop the watchers and shutdown the
+// HTTP server gracefully, waiting for in-flight requests to finish.
 //
-func (srv *server) start() {
-	if srv.opts.Development {
-		srv.autoGenerate()
+func (srv *server) start(ctx context.Context) (err error) {
+	if srv.opts.IsDevelopment {
+		err = srv.autoGenerate()
+		if err != nil {
+			return err
+		}
 	}
 
 	fmt.Printf("ciigo: starting HTTP server at %q for %q\n",
 		srv.opts.Address, srv.opts.Root)
 
-	err := srv.http.Start()
+	errStart := make(chan error, 1)
+	go func() {
+		errStart <- srv.http.Start()
+	}()
+
+	select {
+	case err = <-errStart:
+		srv.stopWatchers()
+		if err != nil {
+			return fmt.Errorf("server.start: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	return srv.stop()
+}
+
+//
+// stop the watchers and shutdown the HTTP server.
+//
+func (srv *server) stop() (err error) {
+	fmt.Printf("ciigo: stopping HTTP server at %q\n", srv.opts.Address)
+
+	srv.stopWatchers()
+
+	if srv.liveReload != nil {
+		srv.liveReload.close()
+	}
+
+	err = srv.http.Stop(0)
 	if err != nil {
-		log.Fatal("web: Start: " + err.Error())
+		return fmt.Errorf("server.stop: %w", err)
 	}
+
+	return nil
 }
 
-func (srv *server) autoGenerate() {
+func (srv *server) stopWatchers() {
+	if srv.dw != nil {
+		srv.dw.Stop()
+		srv.dw = nil
+	}
+	if srv.tmplWatcher != nil {
+		srv.tmplWatcher.Stop()
+		srv.tmplWatcher = nil
+	}
+	if srv.tmplSearchWatcher != nil {
+		srv.tmplSearchWatcher.Stop()
+		srv.tmplSearchWatcher = nil
+	}
+	if srv.tmplDirWatcher != nil {
+		srv.tmplDirWatcher.Stop()
+		srv.tmplDirWatcher = nil
+	}
+}
+
+func (srv *server) autoGenerate() (err error) {
 	srv.dw = &libio.DirWatcher{
-		Path:  srv.opts.Root,
-		Delay: time.Second,
-		Includes: []string{
-			`.*\.adoc$`,
-			`.*\.md$`,
-		},
+		Path:     srv.opts.Root,
+		Delay:    time.Second,
+		Includes: markupPatterns(),
 		Excludes: []string{
 			`assets/.*`,
 			`.*\.html$`,
@@ -111,57 +230,293 @@ func (srv *server) autoGenerate() {
 		},
 		Callback: srv.onChangeFileMarkup,
 	}
+	srv.dw.Excludes = append(srv.dw.Excludes, srv.opts.Exclude...)
 
-	err := srv.dw.Start()
+	err = srv.dw.Start()
 	if err != nil {
-		log.Fatal("ciigo: autoGenerate: " + err.Error())
+		return fmt.Errorf("server.autoGenerate: %w", err)
 	}
 
-	if len(srv.htmlg.path) > 0 {
-		_, err = libio.NewWatcher(srv.htmlg.path, 0, srv.onChangeHTMLTemplate)
+	// The HTML template inside the template directory is watched by
+	// the tmplDirWatcher.
+	if len(srv.htmlg.path) > 0 && !srv.htmlg.isInTemplateDir(srv.htmlg.path) {
+		srv.tmplWatcher, err = libio.NewWatcher(srv.htmlg.path, 0,
+			srv.onChangeHTMLTemplate)
 		if err != nil {
-			log.Fatal("ciigo: autoGenerate: " + err.Error())
+			return fmt.Errorf("server.autoGenerate: %w", err)
 		}
 	}
+
+	if len(srv.htmlg.searchTemplate) > 0 {
+		srv.tmplSearchWatcher, err = libio.NewWatcher(
+			srv.htmlg.searchTemplate, 0, srv.onChangeSearchTemplate)
+		if err != nil {
+			return fmt.Errorf("server.autoGenerate: %w", err)
+		}
+	}
+
+	if len(srv.htmlg.templateDir) > 0 {
+		srv.tmplDirWatcher = &libio.DirWatcher{
+			Path:     srv.htmlg.templateDir,
+			Delay:    time.Second,
+			Excludes: []string{`^\..*`},
+			Callback: srv.onChangeTemplateDir,
+		}
+
+		err = srv.tmplDirWatcher.Start()
+		if err != nil {
+			return fmt.Errorf("server.autoGenerate: %w", err)
+		}
+	}
+
+	return nil
 }
 
-func (srv *server) initHTMLGenerator(htmlTemplate string) {
-	if len(htmlTemplate) == 0 {
-		srv.htmlg = newHTMLGenerator("", templateIndexHTML)
-		return
+//
+// lockMemfs wrap the HTTP handler to prevent the watchers from removing the
+// node of memory file system while the requests read it.
+// The requests hold the read lock, so they are still served concurrently.
+// The requests to internal endpoints, for example the search and the live
+// reload events, does not read the memory file system, so they are not
+// locked.
+//
+func (srv *server) lockMemfs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, pathInternal) {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		srv.memfsMu.RLock()
+		defer srv.memfsMu.RUnlock()
+
+		next.ServeHTTP(res, req)
+	})
+}
+
+//
+// initLiveReload register the live reload endpoint and inject the live
+// reload script into each HTML page served by the server.
+//
+func (srv *server) initLiveReload() (err error) {
+	srv.liveReload = newLiveReload()
+
+	epLiveReload := &libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         pathLiveReload,
+		RequestType:  libhttp.RequestTypeNone,
+		ResponseType: libhttp.ResponseTypePlain,
+		Call:         srv.liveReload.onEvents,
 	}
 
-	var (
-		bhtml       []byte
-		err         error
-		htmlContent string
-	)
+	err = srv.http.RegisterEndpoint(epLiveReload)
+	if err != nil {
+		return fmt.Errorf("server.initLiveReload: %w", err)
+	}
 
-	htmlTemplate = filepath.Clean(htmlTemplate)
+	srv.http.Handler = srv.liveReload.handler(srv.http.Handler)
 
-	if srv.opts.Development {
-		bhtml, err = ioutil.ReadFile(htmlTemplate)
-		if err != nil {
-			log.Fatal("server.initHTMLGenerator: " + err.Error())
+	return nil
+}
+
+func (srv *server) initHTMLGenerator() (err error) {
+	htmlContent, err := srv.loadTemplate(srv.opts.HTMLTemplate,
+		loadHTMLTemplate)
+	if err != nil {
+		return fmt.Errorf("server.initHTMLGenerator: %w", err)
+	}
+
+	searchContent, err := srv.loadTemplate(srv.opts.SearchTemplate,
+		loadSearchTemplate)
+	if err != nil {
+		return fmt.Errorf("server.initHTMLGenerator: %w", err)
+	}
+
+	layouts, err := srv.loadLayouts(srv.opts.TemplateDir)
+	if err != nil {
+		return fmt.Errorf("server.initHTMLGenerator: %w", err)
+	}
+
+	srv.htmlg, err = newHTMLGenerator(&srv.opts.ConvertOptions, htmlContent,
+		layouts)
+	if err != nil {
+		return fmt.Errorf("server.initHTMLGenerator: %w", err)
+	}
+
+	err = srv.htmlg.setSearchTemplate(searchContent)
+	if err != nil {
+		return fmt.Errorf("server.initHTMLGenerator: %w", err)
+	}
+
+	if !srv.opts.IsDevelopment {
+		srv.htmlg.index = srv.loadSearchIndex()
+		srv.htmlg.nav = srv.loadNavigation()
+	}
+
+	return nil
+}
+
+//
+// loadTemplate read the content of template file using the function "load"
+// in development mode or if the file is empty, otherwise read it from
+// Memfs, where its embedded by Generate.
+//
+func (srv *server) loadTemplate(file string, load func(string) (string, error)) (
+	content string, err error,
+) {
+	if len(file) == 0 || srv.opts.IsDevelopment {
+		return load(file)
+	}
+
+	file = filepath.Clean(file)
+
+	node, err := srv.http.Memfs.Get(file)
+	if err != nil {
+		return "", fmt.Errorf("Memfs.Get %s: %w", file, err)
+	}
+
+	b, err := node.Decode()
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+//
+// loadLayouts read the layout templates inside directory "dir" from file
+// system in development mode or if the dir is empty, otherwise read them
+// from Memfs, where they are embedded by Generate.
+//
+func (srv *server) loadLayouts(dir string) (layouts map[string]string, err error) {
+	if len(dir) == 0 || srv.opts.IsDevelopment {
+		return loadLayouts(dir)
+	}
+
+	dir = filepath.Clean(dir)
+
+	node, err := srv.http.Memfs.Get(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Memfs.Get %s: %w", dir, err)
+	}
+
+	layouts = make(map[string]string, len(node.Childs))
+
+	for _, child := range node.Childs {
+		if child.IsDir() || !strings.EqualFold(path.Ext(child.Name()), extLayout) {
+			continue
 		}
-	} else {
-		tmplNode, err := srv.http.Memfs.Get(htmlTemplate)
+
+		b, err := child.Decode()
 		if err != nil {
-			log.Fatalf("server.initHTMLGenerator: Memfs.Get %s: %s",
-				htmlTemplate, err.Error())
+			return nil, err
 		}
-		bhtml, err = tmplNode.Decode()
-		if err != nil {
-			log.Fatal("server.initHTMLGenerator: " + err.Error())
+
+		layouts[layoutName(child.Name())] = string(b)
+	}
+
+	return layouts, nil
+}
+
+//
+// search the pages that match with query using the search index, or using
+// Memfs.Search if the search index does not exist.
+//
+func (srv *server) search(q string) (results []*SearchResult) {
+	if srv.htmlg.index != nil {
+		return srv.htmlg.index.search(q)
+	}
+
+	for _, result := range srv.http.Memfs.Search(strings.Fields(q), 0) {
+		results = append(results, &SearchResult{
+			Path:     result.Path,
+			Snippets: result.Snippets,
+		})
+	}
+
+	return results
+}
+
+//
+// searchResponse search the pages using the query parameter "q", and
+// return the results on page "page" with "limit" results per page.
+//
+func (srv *server) searchResponse(form url.Values) *SearchResponse {
+	q := form.Get("q")
+	page, _ := strconv.Atoi(form.Get("page"))
+	limit, _ := strconv.Atoi(form.Get("limit"))
+
+	return newSearchResponse(q, page, limit, srv.search(q))
+}
+
+//
+// loadSearchIndex load the search index embedded in Memfs.
+// It will return nil if the search index does not exist, for example
+// generated by older ciigo version.
+//
+func (srv *server) loadSearchIndex() *searchIndex {
+	content, err := srv.loadGenerated(fileSearchIndex)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("ciigo: loadSearchIndex: " + err.Error())
 		}
+		return nil
+	}
 
-		// Set to empty value to prevent watcher running on template
-		// file.
-		htmlTemplate = ""
+	idx, err := loadSearchIndex(content)
+	if err != nil {
+		log.Println("ciigo: " + err.Error())
+		return nil
 	}
 
-	htmlContent = string(bhtml)
-	srv.htmlg = newHTMLGenerator(htmlTemplate, htmlContent)
+	return idx
+}
+
+//
+// loadGenerated read the content of internal file "name" inside the
+// directory of Memfs, from Memfs where its embedded by Generate, or from
+// file system if its not embedded.
+// The internal file is embedded using its path, the same as the HTML
+// template, so its not served by the server.
+//
+func (srv *server) loadGenerated(name string) (content []byte, err error) {
+	dir := srv.opts.Root
+	root, err := srv.http.Memfs.Get("/")
+	if err == nil {
+		dir = root.SysPath
+	}
+
+	file := filepath.Join(dir, name)
+
+	node, err := srv.http.Memfs.Get(file)
+	if err != nil {
+		return ioutil.ReadFile(file)
+	}
+
+	return node.Decode()
+}
+
+//
+// loadNavigation load the navigation tree embedded in Memfs.
+// It will return empty navigation if the file does not exist, for example
+// generated by older ciigo version.
+//
+func (srv *server) loadNavigation() *NavNode {
+	content, err := srv.loadGenerated(fileNavigation)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("ciigo: loadNavigation: " + err.Error())
+		}
+		return &NavNode{}
+	}
+
+	nav, err := loadNavigation(content)
+	if err != nil {
+		log.Println("ciigo: " + err.Error())
+		return &NavNode{}
+	}
+
+	return nav
 }
 
 //
@@ -169,13 +524,17 @@ func (srv *server) initHTMLGenerator(htmlTemplate string) {
 // and re-generate them into HTML file when changed.
 //
 func (srv *server) onChangeFileMarkup(ns *libio.NodeState) {
-	if ns.State == libio.FileStateDeleted {
-		fmt.Printf("ciigo: onChangeFileMarkup: %q deleted\n", ns.Node.SysPath)
+	ext := strings.ToLower(path.Ext(ns.Node.SysPath))
+	if !isExtensionMarkup(ext) {
 		return
 	}
 
-	ext := strings.ToLower(path.Ext(ns.Node.SysPath))
-	if !isExtensionMarkup(ext) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if ns.State == libio.FileStateDeleted {
+		fmt.Printf("ciigo: onChangeFileMarkup: %q deleted\n", ns.Node.SysPath)
+		srv.removeFileMarkup(ns.Node.SysPath)
 		return
 	}
 
@@ -212,14 +571,119 @@ func (srv *server) onChangeFileMarkup(ns *libio.NodeState) {
 
 			srv.fileMarkups = append(srv.fileMarkups, fmarkup)
 		}
+
+	default:
+		return
 	}
 
-	fhtml := &fileHTML{
-		path: fmarkup.basePath + ".html",
+	srv.convertFileMarkups(fmarkup.htmlPath)
+}
+
+//
+// convertFileMarkups convert the changed markup files, update the feeds,
+// and notify the browsers to reload the page in htmlPath, or all pages if
+// the navigation tree has changed.
+//
+func (srv *server) convertFileMarkups(htmlPath string) {
+	isNavChanged, err := srv.htmlg.convertFileMarkups(srv.fileMarkups, false)
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = srv.updatePublished()
+	if err != nil {
+		log.Println("ciigo: " + err.Error())
+	}
+
+	if isNavChanged {
+		srv.liveReload.broadcast(liveReloadAll)
+		return
+	}
+	srv.liveReload.broadcast(srv.nodePath(htmlPath))
+}
+
+//
+// updatePublished remove the HTML files of unpublished pages from the memory
+// file system, and write the feeds of the published pages.
+//
+func (srv *server) updatePublished() (err error) {
+	for _, fmarkup := range srv.fileMarkups {
+		if !srv.htmlg.isPublished(fmarkup) {
+			srv.removeMemfsNode(fmarkup.htmlPath)
+		}
+	}
+
+	return writeFeeds(srv.opts.Root, srv.opts.Root, srv.opts.BaseURL,
+		srv.opts.Feeds, srv.htmlg.published(srv.fileMarkups))
+}
+
+//
+// removeFileMarkup remove the generated HTML file of the deleted markup file
+// from the file system, the list of markup files, the build cache, and the
+// memory file system, so the page is not served nor searchable anymore.
+//
+func (srv *server) removeFileMarkup(markupPath string) {
+	var htmlPath string
+
+	for x, fmarkup := range srv.fileMarkups {
+		if fmarkup.path != markupPath {
+			continue
+		}
+		htmlPath = fmarkup.htmlPath
+		srv.fileMarkups = append(srv.fileMarkups[:x], srv.fileMarkups[x+1:]...)
+		break
+	}
+	if len(htmlPath) == 0 {
+		ext := path.Ext(markupPath)
+		htmlPath = strings.TrimSuffix(markupPath, ext) + ".html"
+	}
+
+	err := os.Remove(htmlPath)
+	if err != nil && !os.IsNotExist(err) {
+		log.Println("ciigo: removeFileMarkup: " + err.Error())
 	}
 
-	fhtml.rawBody.Reset()
-	srv.htmlg.convert(fmarkup, fhtml, true)
+	srv.htmlg.cache.remove(markupPath)
+	srv.removeMemfsNode(htmlPath)
+
+	// Removing the page may change the navigation tree of other pages.
+	srv.convertFileMarkups(htmlPath)
+}
+
+//
+// nodePath return the path of file in memory file system, which is also the
+// URL path, based on its path in file system.
+//
+func (srv *server) nodePath(sysPath string) string {
+	rel, err := filepath.Rel(srv.opts.Root, sysPath)
+	if err != nil {
+		return sysPath
+	}
+	return "/" + filepath.ToSlash(rel)
+}
+
+//
+// removeMemfsNode remove the node of file in "sysPath" from the memory file
+// system.
+//
+func (srv *server) removeMemfsNode(sysPath string) {
+	nodePath := srv.nodePath(sysPath)
+
+	srv.memfsMu.Lock()
+	defer srv.memfsMu.Unlock()
+
+	parent, err := srv.http.Memfs.Get(path.Dir(nodePath))
+	if err != nil {
+		return
+	}
+
+	name := path.Base(nodePath)
+	for _, child := range parent.Childs {
+		if child.Name() == name {
+			srv.http.Memfs.RemoveChild(parent, child)
+			return
+		}
+	}
 }
 
 func (srv *server) onChangeHTMLTemplate(ns *libio.NodeState) {
@@ -228,6 +692,35 @@ func (srv *server) onChangeHTMLTemplate(ns *libio.NodeState) {
 		return
 	}
 
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.reloadTemplate()
+}
+
+//
+// onChangeTemplateDir reload all templates when any file inside the
+// template directory is created, modified, or deleted, since the layouts
+// and the HTML template may include the partial templates.
+//
+func (srv *server) onChangeTemplateDir(ns *libio.NodeState) {
+	if ns.Node.IsDir() {
+		return
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	fmt.Println("web: template changed: " + ns.Node.SysPath)
+
+	srv.reloadTemplate()
+}
+
+//
+// reloadTemplate reload the HTML template and the layout templates, convert
+// all markup files, and reload all opened pages.
+//
+func (srv *server) reloadTemplate() {
 	fmt.Println("web: recompiling HTML template  ...")
 
 	err := srv.htmlg.reloadTemplate()
@@ -238,32 +731,105 @@ func (srv *server) onChangeHTMLTemplate(ns *libio.NodeState) {
 
 	fmt.Println("web: regenerate all markup files ... ")
 
-	srv.htmlg.convertFileMarkups(srv.fileMarkups, true)
+	_, err = srv.htmlg.convertFileMarkups(srv.fileMarkups, true)
+	if err != nil {
+		log.Println("web: " + err.Error())
+	}
+
+	srv.liveReload.broadcast(liveReloadAll)
+}
+
+//
+// onChangeSearchTemplate reload the search template and reload the opened
+// search pages.
+//
+func (srv *server) onChangeSearchTemplate(ns *libio.NodeState) {
+	if ns.State == libio.FileStateDeleted {
+		fmt.Printf("watchSearchTemplate: file %q deleted\n", ns.Node.SysPath)
+		return
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	fmt.Println("web: recompiling search template ...")
+
+	err := srv.htmlg.reloadSearchTemplate()
+	if err != nil {
+		log.Println("watchSearchTemplate: " + err.Error())
+		return
+	}
+
+	srv.liveReload.broadcast(pathSearch)
 }
 
+//
+// onSearch render the search results using the search template, inside
+// the HTML template.
+// Like onSearchJSON, it does not hold the server lock, so the requests are
+// not blocked by the conversion; the search index and the templates are
+// guarded by their own read-write lock.
+//
 func (srv *server) onSearch(res http.ResponseWriter, req *http.Request, reqBody []byte) (
 	resBody []byte, err error,
 ) {
 	var bufSearch, buf bytes.Buffer
 
-	q := req.Form.Get("q")
-	results := srv.http.Memfs.Search(strings.Fields(q), 0)
+	out := srv.searchResponse(req.Form)
+
+	srv.htmlg.mu.RLock()
+	defer srv.htmlg.mu.RUnlock()
 
-	err = srv.htmlg.tmplSearch.Execute(&bufSearch, results)
+	err = srv.htmlg.tmplSearch.Execute(&bufSearch, out)
 	if err != nil {
-		return nil, fmt.Errorf("ciigo.onSearch: " + err.Error())
+		return nil, fmt.Errorf("ciigo.onSearch: %w", err)
 	}
 
 	fhtml := &fileHTML{
-		Body: template.HTML(bufSearch.String()), //nolint: gosec
+		Title:     "Search",
+		Body:      template.HTML(bufSearch.String()), //nolint: gosec
+		Nav:       srv.htmlg.nav,
+		URL:       pathSearch,
+		SearchURL: srv.htmlg.searchURL,
 	}
 
 	err = srv.htmlg.tmpl.Execute(&buf, fhtml)
 	if err != nil {
-		return nil, fmt.Errorf("ciigo.onSearch: " + err.Error())
+		return nil, fmt.Errorf("ciigo.onSearch: %w", err)
 	}
 
 	resBody = buf.Bytes()
 
 	return resBody, nil
 }
+
+//
+// onSearchJSON return the search results as JSON object, with the following
+// format,
+//
+//	{
+//		"query": <string>,
+//		"total": <number>,
+//		"page": <number>,
+//		"limit": <number>,
+//		"results": [{
+//			"path": <string>,
+//			"title": <string>,
+//			"score": <number>,
+//			"snippets": [<string>, ...],
+//			"highlights": [<string>, ...]
+//		}, ...]
+//	}
+//
+func (srv *server) onSearchJSON(res http.ResponseWriter, req *http.Request, reqBody []byte) (
+	resBody []byte, err error,
+) {
+	out := srv.searchResponse(req.Form)
+
+	resBody, err = json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("ciigo.onSearchJSON: %w", err)
+	}
+
+	return resBody, nil
+}
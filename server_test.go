@@ -0,0 +1,174 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestServeOptions_OutputDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "ciigo-serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	outDir, err := ioutil.TempDir("", "ciigo-serve-out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+
+	err = ioutil.WriteFile(filepath.Join(root, "index.adoc"),
+		[]byte("= Title\n\nHello.\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &ServeOptions{
+		ConvertOptions: ConvertOptions{
+			Root:      root,
+			OutputDir: outDir,
+		},
+		Address:       "127.0.0.1:0",
+		IsDevelopment: true,
+	}
+
+	err = opts.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, "OutputDir", "", opts.OutputDir, true)
+
+	srv, err := newServer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = os.Stat(filepath.Join(root, "index.html"))
+	test.Assert(t, "HTML file in Root", nil, err, true)
+
+	fis, err := ioutil.ReadDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, "files in OutputDir", 0, len(fis), true)
+
+	req := httptest.NewRequest(http.MethodGet, "/index.html", nil)
+	res := httptest.NewRecorder()
+
+	srv.http.Handler.ServeHTTP(res, req)
+
+	test.Assert(t, "GET /index.html", http.StatusOK, res.Code, true)
+}
+
+func TestServer_onSearchJSON(t *testing.T) {
+	root, err := ioutil.TempDir("", "ciigo-search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"index.adoc": "= Golang\n\nThe Go programming language.\n",
+		"post.md":    "---\ntitle: Post\n---\n\nWriting go code.\n",
+		"other.md":   "---\ntitle: Other\n---\n\nNothing here.\n",
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(root, name), []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &ServeOptions{
+		ConvertOptions: ConvertOptions{
+			Root: root,
+		},
+		Address:       "127.0.0.1:0",
+		IsDevelopment: true,
+	}
+
+	err = opts.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := newServer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		desc     string
+		query    string
+		expTotal int
+		expPage  int
+		expLimit int
+		expPaths []string
+	}{{
+		desc:     "With matched pages",
+		query:    "q=go",
+		expTotal: 2,
+		expPage:  1,
+		expLimit: searchLimitDefault,
+		expPaths: []string{"/index.html", "/post.html"},
+	}, {
+		desc:     "With page and limit",
+		query:    "q=go&page=2&limit=1",
+		expTotal: 2,
+		expPage:  2,
+		expLimit: 1,
+		expPaths: []string{"/post.html"},
+	}, {
+		desc:     "Without matched pages",
+		query:    "q=python",
+		expPage:  1,
+		expLimit: searchLimitDefault,
+		expPaths: []string{},
+	}, {
+		desc:     "Without query",
+		expPage:  1,
+		expLimit: searchLimitDefault,
+		expPaths: []string{},
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		req := httptest.NewRequest(http.MethodGet, pathSearchJSON+"?"+c.query, nil)
+		res := httptest.NewRecorder()
+
+		srv.http.Handler.ServeHTTP(res, req)
+
+		test.Assert(t, "status", http.StatusOK, res.Code, true)
+
+		got := &SearchResponse{}
+		err = json.Unmarshal(res.Body.Bytes(), got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		paths := make([]string, 0, len(got.Results))
+		for _, result := range got.Results {
+			paths = append(paths, result.Path)
+			test.Assert(t, "snippets", true, len(result.Snippets) > 0, true)
+		}
+
+		test.Assert(t, "total", c.expTotal, got.Total, true)
+		test.Assert(t, "page", c.expPage, got.Page, true)
+		test.Assert(t, "limit", c.expLimit, got.Limit, true)
+		test.Assert(t, "paths", c.expPaths, paths, true)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	fileSitemap  = "sitemap.xml"
+	sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	sitemapDate  = "2006-01-02"
+)
+
+//
+// sitemapURLSet represent the root element of sitemap.
+//
+type sitemapURLSet struct {
+	XMLName xml.Name      `xml:"urlset"`
+	XMLNS   string        `xml:"xmlns,attr"`
+	URLs    []*sitemapURL `xml:"url"`
+}
+
+//
+// sitemapURL represent single page in sitemap.
+//
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+//
+// writeSitemap write the sitemap of all pages into file "sitemap.xml"
+// inside the directory "dir".
+// The location of each page is the "baseURL" joined with the page path
+// relative to the "root" directory.
+// The last modification time of each page is taken from its metadata
+// "date", or from the markup file modification time if the date is empty
+// or its format is not supported.
+//
+func writeSitemap(dir, root, baseURL string, fileMarkups []*fileMarkup) (
+	err error,
+) {
+	urlset := &sitemapURLSet{
+		XMLNS: sitemapXMLNS,
+		URLs:  make([]*sitemapURL, 0, len(fileMarkups)),
+	}
+
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	for _, fmarkup := range fileMarkups {
+		loc, err := pageURL(root, fmarkup)
+		if err != nil {
+			return fmt.Errorf("writeSitemap: %w", err)
+		}
+
+		surl := &sitemapURL{
+			Loc: baseURL + loc,
+		}
+
+		t, ok := fmarkup.page.time()
+		if ok {
+			surl.LastMod = t.Format(sitemapDate)
+		} else if fmarkup.info != nil {
+			surl.LastMod = fmarkup.info.ModTime().Format(sitemapDate)
+		}
+
+		urlset.URLs = append(urlset.URLs, surl)
+	}
+
+	err = writeXML(filepath.Join(dir, fileSitemap), urlset)
+	if err != nil {
+		return fmt.Errorf("writeSitemap: %w", err)
+	}
+
+	return nil
+}
+
+//
+// pageURL return the absolute URL path of page generated from markup file,
+// relative to the root directory.
+// The URL of "index" page is its directory, for example "/sub/" for
+// "sub/index.adoc".
+//
+func pageURL(root string, fmarkup *fileMarkup) (string, error) {
+	rel, err := filepath.Rel(root, fmarkup.basePath)
+	if err != nil {
+		return "", err
+	}
+
+	rel = filepath.ToSlash(rel)
+	if rel == "index" {
+		return "/", nil
+	}
+	if strings.HasSuffix(rel, "/index") {
+		return "/" + strings.TrimSuffix(rel, "index"), nil
+	}
+
+	return "/" + rel + ".html", nil
+}
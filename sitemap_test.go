@@ -0,0 +1,116 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestWriteSitemap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ciigo-sitemap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	markupFile := filepath.Join(dir, "page.adoc")
+	err = ioutil.WriteFile(markupFile, []byte("= Page\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2020, 6, 1, 0, 0, 0, 0, time.Local)
+	err = os.Chtimes(markupFile, mtime, mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(markupFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPage := func(name, date string) *fileMarkup {
+		return &fileMarkup{
+			basePath: filepath.Join("content", filepath.FromSlash(name)),
+			info:     info,
+			page:     pageInfo{Date: date},
+		}
+	}
+
+	cases := []struct {
+		desc    string
+		baseURL string
+		pages   []*fileMarkup
+		exp     []*sitemapURL
+	}{{
+		desc:    "Without pages",
+		baseURL: "https://example.com",
+		exp:     []*sitemapURL{},
+	}, {
+		desc:    "With index pages",
+		baseURL: "https://example.com/",
+		pages: []*fileMarkup{
+			newPage("index", "2020-07-01"),
+			newPage("sub/index", "2 July 2020"),
+			newPage("sub/page", "2020-07-03 10:00"),
+		},
+		exp: []*sitemapURL{{
+			Loc:     "https://example.com/",
+			LastMod: "2020-07-01",
+		}, {
+			Loc:     "https://example.com/sub/",
+			LastMod: "2020-07-02",
+		}, {
+			Loc:     "https://example.com/sub/page.html",
+			LastMod: "2020-07-03",
+		}},
+	}, {
+		desc:    "With unsupported date",
+		baseURL: "https://example.com/docs",
+		pages: []*fileMarkup{
+			newPage("page", "next week"),
+			newPage("other", ""),
+		},
+		exp: []*sitemapURL{{
+			Loc:     "https://example.com/docs/page.html",
+			LastMod: "2020-06-01",
+		}, {
+			Loc:     "https://example.com/docs/other.html",
+			LastMod: "2020-06-01",
+		}},
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		err = writeSitemap(dir, "content", c.baseURL, c.pages)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, fileSitemap))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := &sitemapURLSet{}
+		err = xml.Unmarshal(b, got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.Assert(t, "xmlns", sitemapXMLNS, got.XMLNS, true)
+		if got.URLs == nil {
+			got.URLs = []*sitemapURL{}
+		}
+		test.Assert(t, "urls", c.exp, got.URLs, true)
+	}
+}
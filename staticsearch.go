@@ -0,0 +1,223 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path"
+)
+
+const (
+	// dirStaticSearch is the directory, inside the output directory,
+	// where the static search files are written.
+	dirStaticSearch = "search"
+
+	pathStaticSearch = "/" + dirStaticSearch + "/"
+)
+
+const templateStaticSearch = `<form class="search" action="` + pathStaticSearch + `">
+	<input type="text" name="q" placeholder="Search" />
+</form>
+<div id="search-results"></div>
+<script src="` + pathStaticSearch + `search.js"></script>`
+
+//
+// scriptStaticSearch search the pages using the search index
+// "search/index.json" and render the results inside the element
+// "search-results".
+// The pages are matched and ranked the same way as searchIndex.search in
+// ciigo server, using the same word weights and constants.
+//
+const scriptStaticSearch = `(function () {
+	"use strict";
+
+	var wordMin = %d;
+	var prefixWeight = %g;
+
+	var script = document.currentScript;
+	var container = document.getElementById("search-results");
+	var query = new URLSearchParams(window.location.search).get("q") || "";
+
+	function searchTokens(text) {
+		return text.toLowerCase().split(/[^\p{L}\p{N}]+/u).filter(function (word) {
+			return Array.from(word).length >= wordMin;
+		});
+	}
+
+	// lowerBound return the index of the first word that is not less
+	// than token.
+	function lowerBound(words, token) {
+		var low = 0;
+		var high = words.length;
+		while (low < high) {
+			var mid = (low + high) >>> 1;
+			if (words[mid] < token) {
+				low = mid + 1;
+			} else {
+				high = mid;
+			}
+		}
+		return low;
+	}
+
+	function search(index, tokens) {
+		var words = Object.keys(index.terms).sort();
+		var scores = null;
+
+		tokens.forEach(function (token) {
+			var tokenScores = {};
+
+			// The words that start with token are sorted right
+			// after the token itself.
+			for (var x = lowerBound(words, token); x < words.length; x++) {
+				var word = words[x];
+				if (word.indexOf(token) !== 0) {
+					break;
+				}
+				var weight = word === token ? 1 : prefixWeight;
+				var posting = index.terms[word];
+				for (var y = 0; y < posting.length; y += 2) {
+					var doc = posting[y];
+					tokenScores[doc] = (tokenScores[doc] || 0) + weight * posting[y + 1];
+				}
+			}
+
+			if (scores === null) {
+				scores = tokenScores;
+				return;
+			}
+			Object.keys(scores).forEach(function (doc) {
+				if (!(doc in tokenScores)) {
+					delete scores[doc];
+					return;
+				}
+				scores[doc] += tokenScores[doc];
+			});
+		});
+
+		var results = Object.keys(scores).map(function (doc) {
+			return {
+				doc: index.docs[doc],
+				score: scores[doc]
+			};
+		});
+		results.sort(function (a, b) {
+			if (a.score !== b.score) {
+				return b.score - a.score;
+			}
+			return a.doc.path < b.doc.path ? -1 : 1;
+		});
+		return results;
+	}
+
+	function render(results) {
+		var el = document.createElement("h3");
+		el.textContent = "Search result";
+		container.appendChild(el);
+
+		results.forEach(function (result) {
+			var h4 = document.createElement("h4");
+			var link = document.createElement("a");
+			link.href = result.doc.path;
+			link.textContent = result.doc.title || result.doc.path;
+			h4.appendChild(link);
+			container.appendChild(h4);
+
+			var text = result.doc.summary || result.doc.text;
+			if (text) {
+				var p = document.createElement("p");
+				p.textContent = text;
+				container.appendChild(p);
+			}
+		});
+	}
+
+	var form = document.querySelector("form.search input[name=q]");
+	if (form) {
+		form.value = query;
+	}
+
+	var tokens = searchTokens(query);
+	if (tokens.length === 0 || !container) {
+		return;
+	}
+
+	fetch(new URL("index.json", script.src))
+		.then(function (res) {
+			return res.json();
+		})
+		.then(function (index) {
+			render(search(index, tokens));
+		});
+})();
+`
+
+//
+// staticSearchIndex is the format of search index "search/index.json".
+// The Terms map each word to the list of pairs of document index in Docs
+// and the weight of word in that document.
+//
+type staticSearchIndex struct {
+	Docs  []*staticSearchDoc `json:"docs"`
+	Terms map[string][]int   `json:"terms"`
+}
+
+//
+// staticSearchDoc contains the page to be displayed in the static search
+// results.
+// The Text is truncated, it is displayed if the page does not have summary.
+//
+type staticSearchDoc struct {
+	Path    string `json:"path"`
+	Title   string `json:"title"`
+	Summary string `json:"summary,omitempty"`
+	Text    string `json:"text,omitempty"`
+}
+
+//
+// writeStaticSearch write the search page, its script, and the search index
+// into directory "search" inside the output directory.
+// It will return the path of generated files, relative to the output
+// directory.
+//
+func (htmlg *htmlGenerator) writeStaticSearch() (generated []string, err error) {
+	tmpl, err := template.New("search").Parse(templateStaticSearch)
+	if err != nil {
+		return nil, fmt.Errorf("writeStaticSearch: %w", err)
+	}
+
+	file := path.Join(dirStaticSearch, "index.html")
+	err = htmlg.writeGeneratedPage(file, "Search", tmpl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("writeStaticSearch: %w", err)
+	}
+	generated = append(generated, file)
+
+	script := fmt.Sprintf(scriptStaticSearch, searchWordMin,
+		searchPrefixWeight)
+
+	file = path.Join(dirStaticSearch, "search.js")
+	err = htmlg.writeGeneratedFile(file, []byte(script))
+	if err != nil {
+		return nil, fmt.Errorf("writeStaticSearch: %w", err)
+	}
+	generated = append(generated, file)
+
+	index, err := json.Marshal(htmlg.index.export())
+	if err != nil {
+		return nil, fmt.Errorf("writeStaticSearch: %w", err)
+	}
+
+	file = path.Join(dirStaticSearch, "index.json")
+	err = htmlg.writeGeneratedFile(file, index)
+	if err != nil {
+		return nil, fmt.Errorf("writeStaticSearch: %w", err)
+	}
+	generated = append(generated, file)
+
+	return generated, nil
+}
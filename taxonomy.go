@@ -0,0 +1,226 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	metadataCategories = "categories"
+	metadataTags       = "tags"
+)
+
+//
+// taxonomies define the metadata that group the pages, and the directory
+// where their listing pages are generated.
+//
+//nolint: gochecknoglobals
+var taxonomies = []struct {
+	key   string
+	title string
+}{
+	{key: metadataCategories, title: "Categories"},
+	{key: metadataTags, title: "Tags"},
+}
+
+const templateTaxonomyTerm = `<ul class="taxonomy-pages">
+{{- range .}}
+	<li><a href="{{.Path}}">{{.Title}}</a>
+	{{- if .Date}} <span class="date">{{.Date}}</span>{{end}}</li>
+{{- end}}
+</ul>`
+
+const templateTaxonomyIndex = `<ul class="taxonomy-terms">
+{{- range .}}
+	<li><a href="{{.Path}}">{{.Name}}</a> ({{len .Pages}})</li>
+{{- end}}
+</ul>`
+
+//
+// taxonomyTerm contains the pages that have the same tag or category.
+//
+type taxonomyTerm struct {
+	Name  string
+	Path  string
+	Pages []*taxonomyPage
+
+	slug string
+}
+
+//
+// taxonomyPage contains the page to be listed in taxonomy term.
+//
+type taxonomyPage struct {
+	Title string
+	Path  string
+	Date  string
+
+	// sortDate contains the page date as "YYYY-MM-DD" for sorting.
+	sortDate string
+}
+
+//
+// writeTaxonomies generate the listing page for each tag and category, for
+// example "/tags/<name>.html", and their index page, for example
+// "/tags/index.html", using the HTML template.
+// It will return the path of generated pages, relative to the output
+// directory.
+// It will return an error if the generated page has the same path with the
+// page of markup file, for example "tags/index.adoc".
+//
+func (htmlg *htmlGenerator) writeTaxonomies(fileMarkups []*fileMarkup) (
+	generated []string, err error,
+) {
+	markupPaths := make(map[string]string, len(fileMarkups))
+	for _, fmarkup := range fileMarkups {
+		markupPaths[htmlg.urlPath(fmarkup)] = fmarkup.path
+	}
+
+	tmplTerm, err := template.New("term").Parse(templateTaxonomyTerm)
+	if err != nil {
+		return nil, fmt.Errorf("writeTaxonomies: %w", err)
+	}
+	tmplIndex, err := template.New("index").Parse(templateTaxonomyIndex)
+	if err != nil {
+		return nil, fmt.Errorf("writeTaxonomies: %w", err)
+	}
+
+	for _, tax := range taxonomies {
+		terms, err := htmlg.taxonomyTerms(tax.key, fileMarkups)
+		if err != nil {
+			return nil, fmt.Errorf("writeTaxonomies: %w", err)
+		}
+		if len(terms) == 0 {
+			continue
+		}
+
+		files := make([]string, 0, len(terms)+1)
+		for _, term := range terms {
+			files = append(files, path.Join(tax.key, term.slug+".html"))
+		}
+		files = append(files, path.Join(tax.key, "index.html"))
+
+		for _, file := range files {
+			markupPath, ok := markupPaths["/"+file]
+			if ok {
+				return nil, fmt.Errorf("writeTaxonomies: page %q conflict with the page of %s",
+					file, markupPath)
+			}
+		}
+
+		for x, term := range terms {
+			file := files[x]
+			title := tax.title + ": " + term.Name
+
+			err = htmlg.writeGeneratedPage(file, title, tmplTerm, term.Pages)
+			if err != nil {
+				return nil, fmt.Errorf("writeTaxonomies: %w", err)
+			}
+			generated = append(generated, file)
+		}
+
+		file := files[len(terms)]
+
+		err = htmlg.writeGeneratedPage(file, tax.title, tmplIndex, terms)
+		if err != nil {
+			return nil, fmt.Errorf("writeTaxonomies: %w", err)
+		}
+		generated = append(generated, file)
+	}
+
+	return generated, nil
+}
+
+//
+// taxonomyTerms group the pages by the value of their metadata "key",
+// case insensitively.
+// The terms are sorted by name, and the pages in each term are sorted by
+// date, from the newest, and then by title.
+//
+// The slug of each term, the name of its listing page, is generated from
+// the term name in the order of terms.
+// If the slug has been used by the previous term, for example "C" and
+// "C++" that both have slug "c", a number is appended to the slug of later
+// term, "c-2".
+//
+func (htmlg *htmlGenerator) taxonomyTerms(key string, fileMarkups []*fileMarkup) (
+	terms []*taxonomyTerm, err error,
+) {
+	byName := make(map[string]*taxonomyTerm)
+
+	for _, fmarkup := range fileMarkups {
+		names := fmarkup.page.Tags
+		if key == metadataCategories {
+			names = fmarkup.page.Categories
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		pagePath, err := pageURL(htmlg.root, fmarkup)
+		if err != nil {
+			return nil, err
+		}
+
+		page := &taxonomyPage{
+			Title: fmarkup.page.navTitle(path.Base(pagePath)),
+			Path:  pagePath,
+			Date:  fmarkup.page.Date,
+		}
+		t, ok := fmarkup.page.time()
+		if ok {
+			page.sortDate = t.Format(sitemapDate)
+		}
+
+		for _, name := range names {
+			lowerName := strings.ToLower(name)
+
+			term := byName[lowerName]
+			if term == nil {
+				term = &taxonomyTerm{
+					Name: name,
+				}
+				byName[lowerName] = term
+				terms = append(terms, term)
+			}
+			term.Pages = append(term.Pages, page)
+		}
+	}
+
+	sort.Slice(terms, func(x, y int) bool {
+		return strings.ToLower(terms[x].Name) < strings.ToLower(terms[y].Name)
+	})
+
+	slugs := make(map[string]struct{}, len(terms))
+
+	for _, term := range terms {
+		base := headingID(term.Name, make(map[string]int))
+		term.slug = base
+		for n := 2; ; n++ {
+			if _, ok := slugs[term.slug]; !ok {
+				break
+			}
+			term.slug = base + "-" + strconv.Itoa(n)
+		}
+		slugs[term.slug] = struct{}{}
+		term.Path = "/" + key + "/" + term.slug + ".html"
+
+		pages := term.Pages
+		sort.SliceStable(pages, func(x, y int) bool {
+			if pages[x].sortDate != pages[y].sortDate {
+				return pages[x].sortDate > pages[y].sortDate
+			}
+			return pages[x].Title < pages[y].Title
+		})
+	}
+
+	return terms, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestHTMLGenerator_taxonomyTerms(t *testing.T) {
+	htmlg := &htmlGenerator{root: "content"}
+
+	newPage := func(name string, tags ...string) *fileMarkup {
+		return &fileMarkup{
+			basePath: filepath.Join("content", name),
+			page: pageInfo{
+				Title: name,
+				Tags:  tags,
+			},
+		}
+	}
+
+	cases := []struct {
+		desc  string
+		pages []*fileMarkup
+		exp   map[string]string
+	}{{
+		desc: "With different tags that have the same slug",
+		pages: []*fileMarkup{
+			newPage("a", "C", "C++"),
+			newPage("b", "C#"),
+		},
+		exp: map[string]string{
+			"C":   "/tags/c.html",
+			"C#":  "/tags/c-2.html",
+			"C++": "/tags/c-3.html",
+		},
+	}, {
+		desc: "With slug that equal to the numbered slug",
+		pages: []*fileMarkup{
+			newPage("a", "c 2", "C"),
+			newPage("b", "C#"),
+		},
+		exp: map[string]string{
+			"C":   "/tags/c.html",
+			"c 2": "/tags/c-2.html",
+			"C#":  "/tags/c-3.html",
+		},
+	}, {
+		desc: "With the same tag in different case",
+		pages: []*fileMarkup{
+			newPage("a", "Go Lang"),
+			newPage("b", "go lang"),
+		},
+		exp: map[string]string{
+			"Go Lang": "/tags/go-lang.html",
+		},
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		terms, err := htmlg.taxonomyTerms(metadataTags, c.pages)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make(map[string]string, len(terms))
+		for _, term := range terms {
+			got[term.Name] = term.Path
+		}
+
+		test.Assert(t, "terms", c.exp, got, true)
+	}
+}
+
+func TestHTMLGenerator_writeTaxonomies(t *testing.T) {
+	htmlg := &htmlGenerator{root: "content"}
+
+	fileMarkups := []*fileMarkup{{
+		path:     filepath.Join("content", "post.adoc"),
+		basePath: filepath.Join("content", "post"),
+		page: pageInfo{
+			Tags: []string{"go"},
+		},
+	}, {
+		path:     filepath.Join("content", "tags", "index.adoc"),
+		basePath: filepath.Join("content", "tags", "index"),
+	}}
+
+	_, err := htmlg.writeTaxonomies(fileMarkups)
+
+	exp := `writeTaxonomies: page "tags/index.html" conflict with the page of ` +
+		filepath.Join("content", "tags", "index.adoc")
+
+	test.Assert(t, "error", exp, err.Error(), true)
+}
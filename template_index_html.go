@@ -28,10 +28,17 @@ const templateIndexHTML = `<!DOCTYPE html>
 					<a href="/">ciigo</a>
 				</div>
 				<div class="menu">
-					<form class="item" action="/_internal/search">
+					<form class="item" action="{{.SearchURL}}">
 						<input type="text" name="q" placeholder="Search" />
 					</form>
 				</div>
+				<div class="menu">
+					{{- range .Nav.Childs}}
+					{{- if .Path}}
+					<a href="{{.Path}}">{{.Title}}</a>
+					{{- end}}
+					{{- end}}
+				</div>
 			</div>
 		</div>
 
@@ -0,0 +1,31 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+//
+// templateLiveReload contains the script that is injected into HTML pages
+// served in development mode.
+// The script listen to the live reload events and reload the page if the
+// changed path is the current page or if all pages has been changed.
+//
+const templateLiveReload = `
+<script>
+(function() {
+	if (!window.EventSource) {
+		return;
+	}
+	function normalize(p) {
+		return p.replace(/index\.html$/, "").replace(/\/$/, "");
+	}
+	var es = new EventSource("` + pathLiveReload + `");
+	es.onmessage = function(ev) {
+		if (ev.data === "` + liveReloadAll + `" ||
+			normalize(ev.data) === normalize(window.location.pathname)) {
+			window.location.reload();
+		}
+	};
+})();
+</script>
+`
@@ -6,11 +6,29 @@ package ciigo
 
 const templateSearch = `
 <h3> Search result </h3>
-{{range $result := .}}
+<p class="search-total">
+{{- if .Total}}
+	Page {{.Page}} of {{.Pages}}, {{.Total}} pages match with "{{.Query}}".
+{{- else}}
+	No pages match with "{{.Query}}".
+{{- end}}
+</p>
+{{range $result := .Results}}
 <h4>
-<a href="{{$result.Path}}">{{$result.Path}}</a>
+<a href="{{$result.Path}}">{{or $result.Title $result.Path}}</a>
+{{- if $result.Date}} <small class="date">{{$result.Date}}</small>{{end}}
 </h4>
-	{{range $result.Snippets}}
+	{{range $result.Highlights}}
 	<p>... {{.}} ...</p>
 	{{end}}
-{{end}}`
+{{end}}
+{{- if or .PrevPage .NextPage}}
+<p class="search-pages">
+	{{- if .PrevPage}}
+	<a href="?q={{.Query}}&page={{.PrevPage}}&limit={{.Limit}}">Previous</a>
+	{{- end}}
+	{{- if .NextPage}}
+	<a href="?q={{.Query}}&page={{.NextPage}}&limit={{.Limit}}">Next</a>
+	{{- end}}
+</p>
+{{- end}}`
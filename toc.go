@@ -0,0 +1,153 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//nolint: gochecknoglobals
+var (
+	tocHeadingRE = regexp.MustCompile(`(?is)<h([1-6])(\s[^>]*)?>(.*?)</h[1-6]>`)
+	tocIDRE      = regexp.MustCompile(`(?i)\sid="([^"]*)"`)
+	tocTagRE     = regexp.MustCompile(`<[^>]*>`)
+)
+
+//
+// TOCEntry represent a heading in the table of contents of a page.
+//
+type TOCEntry struct {
+	// Level of heading, from 1 for "h1" to 6 for "h6".
+	Level int
+
+	// Text of heading, without HTML tags.
+	Text string
+
+	// ID is the anchor ID of heading, which can be used as link to the
+	// heading, for example "#introduction".
+	ID string
+}
+
+//
+// parseTOC extract the headings from HTML body as table of contents.
+// A heading that does not have ID will be given ID generated from its text,
+// and the body is updated with it.
+// The generated ID does not collide with the ID of other headings.
+//
+func parseTOC(body string) (toc []*TOCEntry, out string) {
+	var (
+		sb   strings.Builder
+		ids  = make(map[string]int)
+		last int
+	)
+
+	locs := tocHeadingRE.FindAllStringSubmatchIndex(body, -1)
+
+	// Reserve the existing IDs first, so the generated ID for the
+	// previous heading does not use them.
+	for _, loc := range locs {
+		if loc[4] < 0 {
+			continue
+		}
+		m := tocIDRE.FindStringSubmatch(body[loc[4]:loc[5]])
+		if m != nil {
+			ids[html.UnescapeString(m[1])]++
+		}
+	}
+
+	for _, loc := range locs {
+		level, _ := strconv.Atoi(body[loc[2]:loc[3]])
+
+		attrs := ""
+		if loc[4] >= 0 {
+			attrs = body[loc[4]:loc[5]]
+		}
+
+		entry := &TOCEntry{
+			Level: level,
+			Text:  headingText(body[loc[6]:loc[7]]),
+		}
+
+		m := tocIDRE.FindStringSubmatch(attrs)
+		if m != nil {
+			entry.ID = html.UnescapeString(m[1])
+		} else {
+			entry.ID = headingID(entry.Text, ids)
+
+			// Insert the ID into heading tag.
+			sb.WriteString(body[last:loc[3]])
+			sb.WriteString(` id="` + html.EscapeString(entry.ID) + `"`)
+			last = loc[3]
+		}
+
+		toc = append(toc, entry)
+	}
+
+	if last == 0 {
+		return toc, body
+	}
+
+	sb.WriteString(body[last:])
+
+	return toc, sb.String()
+}
+
+//
+// headingText return the text of heading HTML without tags.
+//
+func headingText(inner string) string {
+	text := tocTagRE.ReplaceAllString(inner, "")
+	text = html.UnescapeString(text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+//
+// headingID generate unique anchor ID from heading text.
+// The ID contains only lower case letters, digits, and dash.
+// If the same ID has been used before, according to "ids", it will be
+// suffixed with "-N", where N start from 2 and increased until the ID has
+// not been used.
+//
+func headingID(text string, ids map[string]int) string {
+	var (
+		sb     strings.Builder
+		isDash bool
+	)
+
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			isDash = false
+			continue
+		}
+		if !isDash && sb.Len() > 0 {
+			sb.WriteByte('-')
+			isDash = true
+		}
+	}
+
+	id := strings.TrimSuffix(sb.String(), "-")
+	if len(id) == 0 {
+		id = "section"
+	}
+
+	n := ids[id]
+	ids[id] = n + 1
+	if n == 0 {
+		return id
+	}
+
+	for n = 2; ; n++ {
+		uniq := id + "-" + strconv.Itoa(n)
+		if ids[uniq] == 0 {
+			ids[uniq] = 1
+			return uniq
+		}
+	}
+}
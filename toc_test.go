@@ -0,0 +1,75 @@
+// Copyright 2020, Shulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package ciigo
+
+import (
+	"testing"
+
+	"github.com/shuLhan/share/lib/test"
+)
+
+func TestParseTOC(t *testing.T) {
+	cases := []struct {
+		desc    string
+		body    string
+		expTOC  []*TOCEntry
+		expBody string
+	}{{
+		desc: "With existing ID",
+		body: `<h2 id="intro">Intro <em>duction</em></h2>`,
+		expTOC: []*TOCEntry{
+			{Level: 2, Text: "Intro duction", ID: "intro"},
+		},
+		expBody: `<h2 id="intro">Intro <em>duction</em></h2>`,
+	}, {
+		desc: "With generated ID",
+		body: `<h1>Hello, World!</h1><h3 class="x">A &amp; B</h3>`,
+		expTOC: []*TOCEntry{
+			{Level: 1, Text: "Hello, World!", ID: "hello-world"},
+			{Level: 3, Text: "A & B", ID: "a-b"},
+		},
+		expBody: `<h1 id="hello-world">Hello, World!</h1>` +
+			`<h3 id="a-b" class="x">A &amp; B</h3>`,
+	}, {
+		desc: "With duplicate headings",
+		body: `<h2>Intro</h2><h2>Intro</h2><h2>Intro 2</h2>`,
+		expTOC: []*TOCEntry{
+			{Level: 2, Text: "Intro", ID: "intro"},
+			{Level: 2, Text: "Intro", ID: "intro-2"},
+			{Level: 2, Text: "Intro 2", ID: "intro-2-2"},
+		},
+		expBody: `<h2 id="intro">Intro</h2><h2 id="intro-2">Intro</h2>` +
+			`<h2 id="intro-2-2">Intro 2</h2>`,
+	}, {
+		desc: "With generated ID collide with existing ID later",
+		body: `<h2>Setup</h2><h2 id="setup">Setup</h2>`,
+		expTOC: []*TOCEntry{
+			{Level: 2, Text: "Setup", ID: "setup-2"},
+			{Level: 2, Text: "Setup", ID: "setup"},
+		},
+		expBody: `<h2 id="setup-2">Setup</h2><h2 id="setup">Setup</h2>`,
+	}, {
+		desc: "With heading without letters or digits",
+		body: `<h2>***</h2><h2>!!!</h2>`,
+		expTOC: []*TOCEntry{
+			{Level: 2, Text: "***", ID: "section"},
+			{Level: 2, Text: "!!!", ID: "section-2"},
+		},
+		expBody: `<h2 id="section">***</h2><h2 id="section-2">!!!</h2>`,
+	}, {
+		desc:    "Without heading",
+		body:    `<p>Text</p>`,
+		expBody: `<p>Text</p>`,
+	}}
+
+	for _, c := range cases {
+		t.Log(c.desc)
+
+		toc, body := parseTOC(c.body)
+
+		test.Assert(t, "TOC", c.expTOC, toc, true)
+		test.Assert(t, "body", c.expBody, body, true)
+	}
+}